@@ -0,0 +1,366 @@
+package osc
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Arg returns the argument at index `ix` of `msg` as type T. The argument
+// must have exactly that type; use the As* getters of ArgumentsType to
+// convert between numeric types.
+func Arg[T any](msg *Message, ix int) (T, error) {
+	var zero T
+	v, err := msg.Arguments.arg(ix)
+	if err != nil {
+		return zero, err
+	}
+
+	typ := reflect.TypeFor[T]()
+	if v == nil && typ.Kind() == reflect.Interface {
+		return zero, nil
+	}
+
+	t, ok := v.(T)
+	if !ok {
+		return zero, fmt.Errorf("type(%T) is not %s", v, typ)
+	}
+
+	return t, nil
+}
+
+// AsInt Argument getter that converts int32, int64, float32, float64 and
+// bool values to int. Floating point values must not have a fractional part
+// and all values must fit into an int.
+func (args *ArgumentsType) AsInt(ix int) (int, error) {
+	v, err := args.arg(ix)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := coerceInt64(v)
+	if err != nil {
+		return 0, err
+	}
+	if i < math.MinInt || i > math.MaxInt {
+		return 0, fmt.Errorf("value %d out of int range", i)
+	}
+
+	return int(i), nil
+}
+
+// AsFloat64 Argument getter that converts int32, int64, float32, float64 and
+// bool values to float64.
+func (args *ArgumentsType) AsFloat64(ix int) (float64, error) {
+	v, err := args.arg(ix)
+	if err != nil {
+		return 0.0, err
+	}
+
+	return coerceFloat64(v)
+}
+
+// AsBool Argument getter that converts bool, int32, int64, float32 and
+// float64 values to bool. Numbers other than zero are true, Nil is false.
+func (args *ArgumentsType) AsBool(ix int) (bool, error) {
+	v, err := args.arg(ix)
+	if err != nil {
+		return false, err
+	}
+
+	return coerceBool(v)
+}
+
+// AsString Argument getter that converts strings, blobs, numbers and bool
+// values to string.
+func (args *ArgumentsType) AsString(ix int) (string, error) {
+	v, err := args.arg(ix)
+	if err != nil {
+		return "", err
+	}
+
+	return coerceString(v)
+}
+
+// Scan copies the arguments of the message into the values pointed at by
+// `dst`, converting numeric and bool values like the As* getters. Supported
+// destinations are pointers to int, int32, int64, float32, float64, bool,
+// string, []byte, Timetag and any; other types follow the rules of
+// Unmarshal. Surplus arguments are ignored.
+func (msg *Message) Scan(dst ...any) error {
+	if len(dst) > len(msg.Arguments) {
+		return fmt.Errorf("message %s has %d arguments, want %d", msg.Address, len(msg.Arguments), len(dst))
+	}
+
+	for ix, d := range dst {
+		if err := scanArg(msg.Arguments[ix], d); err != nil {
+			return fmt.Errorf("argument %d: %w", ix, err)
+		}
+	}
+
+	return nil
+}
+
+// Args2 returns the first two arguments of `msg`. See Message.Scan.
+func Args2[A, B any](msg *Message) (a A, b B, err error) {
+	err = msg.Scan(&a, &b)
+	return
+}
+
+// Args3 returns the first three arguments of `msg`. See Message.Scan.
+func Args3[A, B, C any](msg *Message) (a A, b B, c C, err error) {
+	err = msg.Scan(&a, &b, &c)
+	return
+}
+
+// Args4 returns the first four arguments of `msg`. See Message.Scan.
+func Args4[A, B, C, D any](msg *Message) (a A, b B, c C, d D, err error) {
+	err = msg.Scan(&a, &b, &c, &d)
+	return
+}
+
+// scanArg stores the argument `v` in the value pointed at by `dst`.
+func scanArg(v any, dst any) error {
+	switch d := dst.(type) {
+	case *int:
+		i, err := coerceInt64(v)
+		if err != nil {
+			return err
+		}
+		if i < math.MinInt || i > math.MaxInt {
+			return fmt.Errorf("value %d out of int range", i)
+		}
+		*d = int(i)
+
+	case *int32:
+		i, err := coerceInt64(v)
+		if err != nil {
+			return err
+		}
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return fmt.Errorf("value %d out of int32 range", i)
+		}
+		*d = int32(i)
+
+	case *int64:
+		i, err := coerceInt64(v)
+		if err != nil {
+			return err
+		}
+		*d = i
+
+	case *float32:
+		f, err := coerceFloat64(v)
+		if err != nil {
+			return err
+		}
+		if math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+			return fmt.Errorf("value %g out of float32 range", f)
+		}
+		*d = float32(f)
+
+	case *float64:
+		f, err := coerceFloat64(v)
+		if err != nil {
+			return err
+		}
+		*d = f
+
+	case *bool:
+		b, err := coerceBool(v)
+		if err != nil {
+			return err
+		}
+		*d = b
+
+	case *string:
+		s, err := coerceString(v)
+		if err != nil {
+			return err
+		}
+		*d = s
+
+	case *any:
+		*d = v
+
+	default:
+		rv := reflect.ValueOf(dst)
+		if rv.Kind() != reflect.Pointer || rv.IsNil() {
+			return fmt.Errorf("destination %T is not a pointer", dst)
+		}
+		return decodeValue(v, rv.Elem())
+	}
+
+	return nil
+}
+
+// coerceInt64 converts a numeric or bool argument to int64.
+func coerceInt64(v any) (int64, error) {
+	switch t := v.(type) {
+	case bool:
+		if t {
+			return 1, nil
+		}
+		return 0, nil
+	case int32, int64, float32, float64:
+		if i, ok := argInt(t); ok {
+			return i, nil
+		}
+		return 0, fmt.Errorf("value %v is not an integer", t)
+	}
+
+	return 0, fmt.Errorf("type(%T) is not a number", v)
+}
+
+// coerceFloat64 converts a numeric or bool argument to float64.
+func coerceFloat64(v any) (float64, error) {
+	switch t := v.(type) {
+	case bool:
+		if t {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	case int32:
+		return float64(t), nil
+	case int64:
+		return float64(t), nil
+	case float32:
+		return float64(t), nil
+	case float64:
+		return t, nil
+	}
+
+	return 0.0, fmt.Errorf("type(%T) is not a number", v)
+}
+
+// coerceBool converts a numeric, bool or nil argument to bool.
+func coerceBool(v any) (bool, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case nil:
+		return false, nil
+	case int32, int64, float32, float64:
+		f, _ := coerceFloat64(t)
+		return f != 0, nil
+	}
+
+	return false, fmt.Errorf("type(%T) is not bool", v)
+}
+
+// coerceString converts a string, blob, numeric or bool argument to string.
+func coerceString(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case []byte:
+		return string(t), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case int32:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(t), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64), nil
+	}
+
+	return "", fmt.Errorf("type(%T) is not string", v)
+}
+
+// OSCArgument is implemented by types that convert themselves to an OSC
+// argument. OSCArgument must return a value of one of the OSC types
+// (bool, int32, int64, float32, float64, string, []byte, Timetag or nil)
+// or of a type convertible by Message.Append.
+type OSCArgument interface {
+	OSCArgument() (any, error)
+}
+
+// toArgument converts the Go value `arg` to an OSC argument. Native OSC types
+// and registered custom types are returned unchanged. In addition, the following conversions are made:
+//   - OSCArgument: the returned value
+//   - time.Time: Timetag
+//   - int, int8, int16, uint, uint8 ... uint64 and named integer types:
+//     int32; the value must fit into an int32. Named int64 types: int64
+//   - named bool, float, string and []byte types: their underlying type
+//   - encoding.BinaryMarshaler: blob
+//   - fmt.Stringer: string
+func toArgument(arg any) (any, error) {
+	return convertArgument(arg, true)
+}
+
+// convertArgument implements toArgument. OSCArgument is only consulted if
+// `custom` is set, so a value returned by OSCArgument can't recurse.
+func convertArgument(arg any, custom bool) (any, error) {
+	if customTypeOf(arg) != nil {
+		return arg, nil
+	}
+
+	switch t := arg.(type) {
+	// OSC types are ok
+	case bool, int32, int64, float32, float64, string, nil, []byte, Timetag, RawArgument:
+		return t, nil
+	case time.Time:
+		return NewTimetagFromTime(t), nil
+	case OSCArgument:
+		if !custom {
+			break
+		}
+		v, err := t.OSCArgument()
+		if err != nil {
+			return nil, err
+		}
+		return convertArgument(v, false)
+	}
+
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+
+	case reflect.Int64:
+		return v.Int(), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		i := v.Int()
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("int32 %d out of range", i)
+		}
+		return int32(i), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := v.Uint()
+		if u > math.MaxInt32 {
+			return nil, fmt.Errorf("int32 %d out of range", u)
+		}
+		return int32(u), nil
+
+	case reflect.Float32:
+		return float32(v.Float()), nil
+
+	case reflect.Float64:
+		return v.Float(), nil
+
+	case reflect.String:
+		return v.String(), nil
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+	}
+
+	switch t := arg.(type) {
+	case encoding.BinaryMarshaler:
+		return t.MarshalBinary()
+	case fmt.Stringer:
+		return t.String(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type: %T", arg)
+}
@@ -0,0 +1,99 @@
+package osc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArg(t *testing.T) {
+	msg := mustMessage("/a", int32(1), "two", nil)
+
+	i, err := Arg[int32](msg, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), i)
+
+	s, err := Arg[string](msg, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "two", s)
+
+	v, err := Arg[any](msg, 2)
+	assert.Nil(t, err)
+	assert.Nil(t, v)
+
+	_, err = Arg[float32](msg, 0)
+	assert.NotNil(t, err)
+
+	_, err = Arg[int32](msg, 3)
+	assert.NotNil(t, err)
+}
+
+func TestArgumentCoercion(t *testing.T) {
+	args := ArgumentsType{int32(3), float32(2), float32(0.5), int64(1 << 40), true, "7", nil}
+
+	for _, tt := range []struct {
+		desc string
+		ix   int
+		i    int
+		iOk  bool
+		f    float64
+		b    bool
+		s    string
+	}{
+		{"int32", 0, 3, true, 3, true, "3"},
+		{"integral float32", 1, 2, true, 2, true, "2"},
+		{"fractional float32", 2, 0, false, 0.5, true, "0.5"},
+		{"int64", 3, 1 << 40, true, 1 << 40, true, "1099511627776"},
+		{"bool", 4, 1, true, 1, true, "true"},
+	} {
+		i, err := args.AsInt(tt.ix)
+		if tt.iOk {
+			assert.Nil(t, err, tt.desc)
+			assert.Equal(t, tt.i, i, tt.desc)
+		} else {
+			assert.NotNil(t, err, tt.desc)
+		}
+
+		f, err := args.AsFloat64(tt.ix)
+		assert.Nil(t, err, tt.desc)
+		assert.Equal(t, tt.f, f, tt.desc)
+
+		b, err := args.AsBool(tt.ix)
+		assert.Nil(t, err, tt.desc)
+		assert.Equal(t, tt.b, b, tt.desc)
+
+		s, err := args.AsString(tt.ix)
+		assert.Nil(t, err, tt.desc)
+		assert.Equal(t, tt.s, s, tt.desc)
+	}
+
+	_, err := args.AsFloat64(5)
+	assert.NotNil(t, err)
+
+	b, err := args.AsBool(6)
+	assert.Nil(t, err)
+	assert.False(t, b)
+}
+
+func TestMessageScan(t *testing.T) {
+	msg := mustMessage("/a", float32(1), int32(2), int32(0), "x", int64(1<<40))
+
+	var i int
+	var f float32
+	var b bool
+	var s string
+	var i32 int32
+	assert.Nil(t, msg.Scan(&i, &f, &b, &s))
+	assert.Equal(t, 1, i)
+	assert.Equal(t, float32(2), f)
+	assert.False(t, b)
+	assert.Equal(t, "x", s)
+
+	assert.NotNil(t, msg.Scan(&i, &f, &b, &s, &i32))
+	assert.NotNil(t, msg.Scan(&i, &f, &b, &s, &i, &i))
+
+	x, y, err := Args2[float64, int](msg)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, x)
+	assert.Equal(t, 2, y)
+}
@@ -0,0 +1,169 @@
+package osc
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMTU is the default maximum size of a datagram sent by a Batcher.
+// It is the Ethernet MTU minus the IPv4 and UDP headers.
+const DefaultMTU = 1472
+
+// Batcher collects outgoing OSC packets and sends them as OSC bundles. A
+// bundle is sent when the batching window elapses, when the maximum number of
+// packets is queued, when adding another packet would exceed the MTU, or when
+// Flush is called.
+type Batcher struct {
+	// MTU is the maximum size in bytes of a marshalled bundle. If zero,
+	// DefaultMTU is used.
+	MTU int
+
+	// Timetag returns the timetag for each bundle. If nil, bundles are sent
+	// with an immediate timetag.
+	Timetag func() Timetag
+
+	sender      Sender
+	window      time.Duration
+	maxMessages int
+
+	mu      sync.Mutex
+	pending []Packet
+	size    int
+	timer   *time.Timer
+	gen     uint64 // incremented by every flush
+	err     error
+}
+
+// NewBatcher returns a new Batcher that sends bundles through `sender`.
+// Queued packets are flushed `window` after the first one was queued or once
+// `maxMessages` packets are queued. A zero `window` or `maxMessages` disables
+// the respective trigger.
+func NewBatcher(sender Sender, window time.Duration, maxMessages int) *Batcher {
+	return &Batcher{
+		sender:      sender,
+		window:      window,
+		maxMessages: maxMessages,
+	}
+}
+
+// Send queues an OSC Bundle or an OSC Message. It returns ErrorPacketTooLarge
+// if the packet doesn't fit into a bundle of MTU bytes on its own. Errors
+// from a flush triggered by the batching window are reported by Err.
+func (b *Batcher) Send(packet Packet) error {
+	size, err := packetSize(packet)
+	if err != nil {
+		return err
+	}
+
+	elemSize := 4 + size
+	if bundleHeaderSize+elemSize > b.mtu() {
+		return ErrorPacketTooLarge
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The packet is queued even if the full batch can't be sent
+	var flushErr error
+	if b.size+elemSize > b.mtu() {
+		flushErr = b.flush()
+	}
+
+	if len(b.pending) == 0 {
+		b.size = bundleHeaderSize
+		if b.window > 0 {
+			gen := b.gen
+			b.timer = time.AfterFunc(b.window, func() { b.flushWindow(gen) })
+		}
+	}
+
+	b.pending = append(b.pending, packet)
+	b.size += elemSize
+
+	if b.maxMessages > 0 && len(b.pending) >= b.maxMessages {
+		if err := b.flush(); flushErr == nil {
+			flushErr = err
+		}
+	}
+
+	return flushErr
+}
+
+// Flush sends all queued packets immediately.
+func (b *Batcher) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.flush()
+}
+
+// Err returns and clears the first error of a flush triggered by the batching
+// window since the last call to Err.
+func (b *Batcher) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.err
+	b.err = nil
+	return err
+}
+
+// Len returns the number of queued packets.
+func (b *Batcher) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.pending)
+}
+
+// flushWindow is called when the batching window of the batch `gen` elapses.
+func (b *Batcher) flushWindow(gen uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The timer may fire after its batch was flushed and a new one started
+	if gen != b.gen {
+		return
+	}
+
+	if err := b.flush(); err != nil && b.err == nil {
+		b.err = err
+	}
+}
+
+// flush sends the queued packets as one bundle. The caller must hold b.mu.
+func (b *Batcher) flush() error {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+
+	if len(b.pending) == 0 {
+		return nil
+	}
+
+	tt := NewImmediateTimetag()
+	if b.Timetag != nil {
+		tt = b.Timetag()
+	}
+
+	bundle := &Bundle{Timetag: tt}
+	for _, p := range b.pending {
+		if err := bundle.Append(p); err != nil {
+			return err
+		}
+	}
+
+	b.pending = nil
+	b.size = 0
+	b.gen++
+
+	return b.sender.Send(bundle)
+}
+
+func (b *Batcher) mtu() int {
+	if b.MTU > 0 {
+		return b.MTU
+	}
+	return DefaultMTU
+}
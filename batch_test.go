@@ -0,0 +1,161 @@
+package osc
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSender is a Sender that stores all packets it is asked to send.
+type recordingSender struct {
+	mu      sync.Mutex
+	packets []Packet
+}
+
+func (r *recordingSender) Send(packet Packet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.packets = append(r.packets, packet)
+	return nil
+}
+
+func (r *recordingSender) sent() []Packet {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Packet(nil), r.packets...)
+}
+
+// failingSender is a Sender that fails while `fail` is set.
+type failingSender struct {
+	recordingSender
+	fail bool
+}
+
+func (f *failingSender) Send(packet Packet) error {
+	f.mu.Lock()
+	fail := f.fail
+	f.mu.Unlock()
+
+	if fail {
+		return errors.New("send failed")
+	}
+	return f.recordingSender.Send(packet)
+}
+
+func (f *failingSender) setFail(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fail = fail
+}
+
+func TestBatcher(t *testing.T) {
+	t.Run("should flush on count", func(t *testing.T) {
+		r := &recordingSender{}
+		b := NewBatcher(r, 0, 3)
+
+		for i := 0; i < 7; i++ {
+			assert.Nil(t, b.Send(mustMessage("/a", int32(i))))
+		}
+
+		sent := r.sent()
+		assert.Equal(t, 2, len(sent))
+		assert.Equal(t, 1, b.Len())
+
+		bundle := sent[0].(*Bundle)
+		assert.Equal(t, NewImmediateTimetag(), bundle.Timetag)
+		assert.Equal(t, 3, len(bundle.Messages))
+		assert.Equal(t, int32(0), bundle.Messages[0].Arguments[0])
+		assert.Equal(t, int32(2), bundle.Messages[2].Arguments[0])
+
+		assert.Nil(t, b.Flush())
+		assert.Equal(t, 3, len(r.sent()))
+		assert.Equal(t, 0, b.Len())
+	})
+
+	t.Run("should flush after window", func(t *testing.T) {
+		r := &recordingSender{}
+		b := NewBatcher(r, 20*time.Millisecond, 0)
+
+		assert.Nil(t, b.Send(mustMessage("/a")))
+		assert.Nil(t, b.Send(mustMessage("/b")))
+		assert.Equal(t, 0, len(r.sent()))
+
+		assert.Eventually(t, func() bool { return len(r.sent()) == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 2, len(r.sent()[0].(*Bundle).Messages))
+	})
+
+	t.Run("should split at MTU", func(t *testing.T) {
+		r := &recordingSender{}
+		b := NewBatcher(r, 0, 0)
+		b.MTU = 64
+
+		// Each message is 12 bytes marshalled, 16 bytes as a bundle element.
+		for i := 0; i < 5; i++ {
+			assert.Nil(t, b.Send(mustMessage("/a", int32(i))))
+		}
+		assert.Nil(t, b.Flush())
+
+		sent := r.sent()
+		assert.Equal(t, 2, len(sent))
+		for _, p := range sent {
+			data, err := p.MarshalBinary()
+			assert.Nil(t, err)
+			assert.LessOrEqual(t, len(data), b.MTU)
+		}
+		assert.Equal(t, 3, len(sent[0].(*Bundle).Messages))
+		assert.Equal(t, 2, len(sent[1].(*Bundle).Messages))
+
+		err := b.Send(mustMessage("/a", make([]byte, 64)))
+		assert.Equal(t, ErrorPacketTooLarge, err)
+	})
+
+	t.Run("should use chosen timetag", func(t *testing.T) {
+		r := &recordingSender{}
+		b := NewBatcher(r, 0, 1)
+		b.Timetag = func() Timetag { return Timetag(42) }
+
+		assert.Nil(t, b.Send(mustMessage("/a")))
+		assert.Equal(t, Timetag(42), r.sent()[0].(*Bundle).Timetag)
+	})
+
+	t.Run("should queue packets after a failed window flush", func(t *testing.T) {
+		f := &failingSender{fail: true}
+		b := NewBatcher(f, 10*time.Millisecond, 0)
+
+		assert.Nil(t, b.Send(mustMessage("/a")))
+		assert.Eventually(t, func() bool { return b.Len() == 0 }, time.Second, 5*time.Millisecond)
+
+		f.setFail(false)
+		assert.Nil(t, b.Send(mustMessage("/b")))
+		assert.Equal(t, 1, b.Len())
+		assert.EqualError(t, b.Err(), "send failed")
+		assert.Nil(t, b.Err())
+
+		assert.Nil(t, b.Flush())
+		sent := f.sent()
+		assert.Equal(t, 1, len(sent))
+		assert.Equal(t, "/b", sent[0].(*Bundle).Messages[0].Address)
+	})
+
+	t.Run("should ignore the window of a flushed batch", func(t *testing.T) {
+		r := &recordingSender{}
+		b := NewBatcher(r, time.Hour, 0)
+
+		assert.Nil(t, b.Send(mustMessage("/a")))
+		assert.Nil(t, b.Flush())
+		assert.Nil(t, b.Send(mustMessage("/b")))
+
+		// A timer of the first batch that fired while Flush held the lock
+		b.flushWindow(0)
+		assert.Equal(t, 1, b.Len())
+		assert.Equal(t, 1, len(r.sent()))
+
+		assert.Nil(t, b.Flush())
+	})
+}
@@ -0,0 +1,149 @@
+package osc
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"golang.org/x/net/ipv4"
+)
+
+// Datagram is an OSC packet and the address it is sent to.
+type Datagram struct {
+	Addr   net.Addr
+	Packet Packet
+}
+
+// WriteBatch sends the datagrams over `conn` in order and returns the number
+// of datagrams sent. If `conn` is a *net.UDPConn, many datagrams are written
+// per system call (sendmmsg) on Linux; otherwise and on other platforms they
+// are written one at a time.
+func WriteBatch(conn net.PacketConn, datagrams []Datagram) (int, error) {
+	udp, ok := conn.(*net.UDPConn)
+	if !ok {
+		return writeEach(conn, datagrams)
+	}
+
+	pc := ipv4.NewPacketConn(udp)
+	family := addrFamily(udp.LocalAddr())
+	msgs := make([]ipv4.Message, 0, len(datagrams))
+	sent := 0
+
+	flush := func() error {
+		for len(msgs) > 0 {
+			n, err := pc.WriteBatch(msgs, 0)
+			sent += n
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				return io.ErrShortWrite
+			}
+			msgs = msgs[n:]
+		}
+		msgs = msgs[:0]
+		return nil
+	}
+
+	for _, d := range datagrams {
+		data, err := d.Packet.MarshalBinary()
+		if err != nil {
+			return sent, err
+		}
+
+		// An IPv4 destination can't be passed as such to a dual stack
+		// IPv6 socket; let the net package map it.
+		if addrFamily(d.Addr) != family {
+			if err := flush(); err != nil {
+				return sent, err
+			}
+			if _, err := conn.WriteTo(data, d.Addr); err != nil {
+				return sent, err
+			}
+			sent++
+			continue
+		}
+
+		msgs = append(msgs, ipv4.Message{Buffers: [][]byte{data}, Addr: d.Addr})
+	}
+
+	return sent, flush()
+}
+
+// writeEach sends the datagrams over `conn` one at a time.
+func writeEach(conn net.PacketConn, datagrams []Datagram) (int, error) {
+	for i, d := range datagrams {
+		data, err := d.Packet.MarshalBinary()
+		if err != nil {
+			return i, err
+		}
+		if _, err := conn.WriteTo(data, d.Addr); err != nil {
+			return i, err
+		}
+	}
+
+	return len(datagrams), nil
+}
+
+// addrFamily returns 4 for IPv4 UDP addresses, 6 for IPv6 UDP addresses and
+// 0 otherwise.
+func addrFamily(addr net.Addr) int {
+	a, ok := addr.(*net.UDPAddr)
+	switch {
+	case !ok:
+		return 0
+	case a.IP.To4() != nil:
+		return 4
+	}
+
+	return 6
+}
+
+// serveBatch reads up to BatchSize datagrams per system call (recvmmsg) from
+// `c` and dispatches them. On platforms other than Linux, one datagram is read
+// per call.
+func (s *Server) serveBatch(c *net.UDPConn) error {
+	tempDelay := 25 + time.Millisecond
+
+	pc := ipv4.NewPacketConn(c)
+	msgs := make([]ipv4.Message, s.BatchSize)
+	bufs := make([]*[]byte, s.BatchSize)
+
+	for i := range msgs {
+		bufs[i] = bufferPool.Get().(*[]byte)
+		msgs[i].Buffers = [][]byte{*bufs[i]}
+	}
+	defer func() {
+		for _, buf := range bufs {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	for {
+		if s.ReadTimeout != 0 {
+			err := c.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+			if err != nil {
+				return err
+			}
+		}
+
+		n, err := pc.ReadBatch(msgs, 0)
+		if err != nil {
+			ne, ok := err.(net.Error)
+
+			if ok && ne.Temporary() {
+				time.Sleep(tempDelay)
+				continue
+			}
+
+			return err
+		}
+
+		for i := range msgs[:n] {
+			err := s.dispatch(msgs[i].Buffers[0][:msgs[i].N], msgs[i].Addr)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
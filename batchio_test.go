@@ -0,0 +1,127 @@
+package osc
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listenLocal returns a UDP connection on a free local port.
+func listenLocal(t *testing.T, network, addr string) *net.UDPConn {
+	laddr, err := net.ResolveUDPAddr(network, addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	conn, err := net.ListenUDP(network, laddr)
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+// receive reads `n` OSC messages from `conn`.
+func receive(t *testing.T, conn net.PacketConn, n int) []string {
+	var addrs []string
+	buf := make([]byte, maxPacketSize)
+
+	assert.Nil(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for i := 0; i < n; i++ {
+		size, _, err := conn.ReadFrom(buf)
+		if !assert.Nil(t, err) {
+			break
+		}
+		p, err := ParsePacket(buf[:size])
+		assert.Nil(t, err)
+		addrs = append(addrs, p.(*Message).Address)
+	}
+
+	return addrs
+}
+
+// packetConn hides the *net.UDPConn type of the wrapped connection.
+type packetConn struct {
+	net.PacketConn
+}
+
+func TestWriteBatch(t *testing.T) {
+	recv := listenLocal(t, "udp4", "127.0.0.1:0")
+
+	for _, tt := range []struct {
+		desc string
+		conn net.PacketConn
+	}{
+		{"udp4", listenLocal(t, "udp4", "127.0.0.1:0")},
+		{"dual stack", listenLocal(t, "udp", ":0")},
+		{"packet conn", packetConn{listenLocal(t, "udp4", "127.0.0.1:0")}},
+	} {
+		var datagrams []Datagram
+		var want []string
+		for i := 0; i < 20; i++ {
+			addr := fmt.Sprintf("/batch/%d", i)
+			datagrams = append(datagrams, Datagram{recv.LocalAddr(), mustMessage(addr, int32(i))})
+			want = append(want, addr)
+		}
+
+		n, err := WriteBatch(tt.conn, datagrams)
+		assert.Nil(t, err, tt.desc)
+		assert.Equal(t, len(datagrams), n, tt.desc)
+		assert.Equal(t, want, receive(t, recv, len(want)), tt.desc)
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	var mu sync.Mutex
+	var got int
+	wg := sync.WaitGroup{}
+	wg.Add(50)
+
+	dispatcher := NewStandardDispatcher()
+	err := dispatcher.AddMsgHandler("*", func(msg *Message) {
+		mu.Lock()
+		got++
+		mu.Unlock()
+		wg.Done()
+	})
+	assert.Nil(t, err)
+
+	server := NewServerAndClient(dispatcher)
+	assert.Nil(t, server.NewConn(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, nil))
+	server.Server().BatchSize = 8
+	server.Server().ZeroCopy = true
+	defer server.Close()
+
+	client := NewServerAndClient(nil)
+	assert.Nil(t, client.NewConn(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, nil))
+	defer client.Close()
+
+	go server.ListenAndServe()
+
+	var datagrams []Datagram
+	for i := 0; i < 50; i++ {
+		msg := mustMessage(fmt.Sprintf("/batch/%d", i), "value")
+		datagrams = append(datagrams, Datagram{server.Conn().LocalAddr(), msg})
+	}
+	assert.Nil(t, client.SendBatch(datagrams...))
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("not all datagrams were dispatched")
+	}
+
+	mu.Lock()
+	assert.Equal(t, 50, got)
+	mu.Unlock()
+}
@@ -1,11 +1,15 @@
 package osc
 
 import (
-	"bytes"
 	"encoding/binary"
+	"fmt"
+	"strings"
 	"time"
 )
 
+// bundleHeaderSize is the size of the "#bundle" string and the timetag.
+const bundleHeaderSize = 16
+
 // Bundle represents an OSC bundle. It consists of the OSC-string "#bundle"
 // followed by an OSC Time Tag, followed by zero or more OSC bundle/message
 // elements. The OSC-timetag is a 64-bit fixed point time tag. See
@@ -35,6 +39,42 @@ func (b *Bundle) Append(pck Packet) error {
 	return nil
 }
 
+// String implements the fmt.Stringer interface. The elements are written on
+// separate, indented lines; the text representation can be parsed with
+// ParseText.
+func (b *Bundle) String() string {
+	if b == nil {
+		return ""
+	}
+
+	var s strings.Builder
+	b.writeString(&s, "")
+
+	return s.String()
+}
+
+// writeString writes the text representation of the bundle to `s`, indenting
+// the elements by `indent` plus a tab.
+func (b *Bundle) writeString(s *strings.Builder, indent string) {
+	s.WriteString(fmt.Sprintf("%s %d {", bundleTagString, uint64(b.Timetag)))
+
+	if len(b.Messages) == 0 && len(b.Bundles) == 0 {
+		s.WriteString("}")
+		return
+	}
+
+	for _, m := range b.Messages {
+		s.WriteString("\n" + indent + "\t" + m.String())
+	}
+
+	for _, nb := range b.Bundles {
+		s.WriteString("\n" + indent + "\t")
+		nb.writeString(s, indent+"\t")
+	}
+
+	s.WriteString("\n" + indent + "}")
+}
+
 // MarshalBinary serializes the OSC bundle to a byte array with the following
 // format:
 // 1. Bundle string: '#bundle'
@@ -44,66 +84,124 @@ func (b *Bundle) Append(pck Packet) error {
 // 5. Length of n OSC bundle element
 // 6. n bundle element.
 func (b *Bundle) MarshalBinary() ([]byte, error) {
-	// Add the '#bundle' string
-	data := new(bytes.Buffer)
-
-	_, err := writePaddedString("#bundle", data)
-	if err != nil {
-		return nil, err
-	}
+	return b.AppendBinary(make([]byte, 0, b.Size()))
+}
 
-	// Add the time tag
-	bd, err := b.Timetag.MarshalBinary()
-	if err != nil {
-		return nil, err
-	}
+// AppendBinary appends the binary representation of the OSC bundle, as
+// returned by MarshalBinary, to `dst` and returns the extended buffer. It
+// doesn't allocate if `dst` has room for Size more bytes, unless a message
+// holds custom type arguments.
+func (b *Bundle) AppendBinary(dst []byte) ([]byte, error) {
+	// Add the '#bundle' string and the time tag
+	dst = appendPaddedString(dst, bundleTagString)
+	dst, _ = b.Timetag.AppendBinary(dst)
 
-	_, err = data.Write(bd)
-	if err != nil {
-		return nil, err
-	}
+	var err error
 
 	// Process all OSC Messages
 	for _, m := range b.Messages {
-		buf, err := m.MarshalBinary()
+		// Reserve room for the length of the OSC message
+		lenPos := len(dst)
+		dst = append(dst, 0, 0, 0, 0)
+
+		dst, err = m.AppendBinary(dst)
 		if err != nil {
 			return nil, err
 		}
 
-		// Append the length of the OSC message
-		err = binary.Write(data, binary.BigEndian, int32(len(buf)))
+		binary.BigEndian.PutUint32(dst[lenPos:], uint32(len(dst)-lenPos-4))
+	}
+
+	// Process all OSC Bundles
+	for _, nb := range b.Bundles {
+		// Reserve room for the size of the bundle
+		lenPos := len(dst)
+		dst = append(dst, 0, 0, 0, 0)
+
+		dst, err = nb.AppendBinary(dst)
 		if err != nil {
 			return nil, err
 		}
 
-		// Append the OSC message
-		_, err = data.Write(buf)
-		if err != nil {
-			return nil, err
+		binary.BigEndian.PutUint32(dst[lenPos:], uint32(len(dst)-lenPos-4))
+	}
+
+	return dst, nil
+}
+
+// Size returns the number of bytes of the binary representation of the OSC
+// bundle.
+func (b *Bundle) Size() int {
+	n := bundleHeaderSize
+
+	for _, m := range b.Messages {
+		n += 4 + m.Size()
+	}
+
+	for _, nb := range b.Bundles {
+		n += 4 + nb.Size()
+	}
+
+	return n
+}
+
+// SplitBundle splits the OSC bundle `b` into bundles whose marshalled size
+// does not exceed `maxSize` bytes. All returned bundles carry the timetag of
+// `b` and the elements keep their order. Nested bundles that are too large
+// are split recursively. An error wrapping ErrorPacketTooLarge is returned if
+// a single message can't fit.
+func SplitBundle(b *Bundle, maxSize int) ([]*Bundle, error) {
+	type element struct {
+		pck  Packet
+		size int
+	}
+
+	var elems []element
+
+	for _, m := range b.Messages {
+		size := m.Size()
+		if bundleHeaderSize+4+size > maxSize {
+			return nil, fmt.Errorf("%w: message %s is %d bytes", ErrorPacketTooLarge, m.Address, size)
 		}
+
+		elems = append(elems, element{m, size})
 	}
 
-	// Process all OSC Bundles
-	for _, b := range b.Bundles {
-		buf, err := b.MarshalBinary()
+	for _, nb := range b.Bundles {
+		parts, err := SplitBundle(nb, maxSize-bundleHeaderSize-4)
 		if err != nil {
 			return nil, err
 		}
 
-		// Write the size of the bundle
-		err = binary.Write(data, binary.BigEndian, int32(len(buf)))
-		if err != nil {
-			return nil, err
+		for _, p := range parts {
+			elems = append(elems, element{p, p.Size()})
 		}
+	}
 
-		// Append the bundle
-		_, err = data.Write(buf)
-		if err != nil {
+	cur := &Bundle{Timetag: b.Timetag}
+	bundles := []*Bundle{cur}
+	size := bundleHeaderSize
+
+	for _, e := range elems {
+		if size+4+e.size > maxSize && size > bundleHeaderSize {
+			cur = &Bundle{Timetag: b.Timetag}
+			bundles = append(bundles, cur)
+			size = bundleHeaderSize
+		}
+
+		if err := cur.Append(e.pck); err != nil {
 			return nil, err
 		}
+		size += 4 + e.size
 	}
 
-	return data.Bytes(), nil
+	return bundles, nil
+}
+
+// SplitMessages packs the OSC messages `msgs` into bundles with the timetag
+// `tt` whose marshalled size does not exceed `maxSize` bytes. See SplitBundle.
+func SplitMessages(tt Timetag, maxSize int, msgs ...*Message) ([]*Bundle, error) {
+	return SplitBundle(&Bundle{Timetag: tt, Messages: msgs}, maxSize)
 }
 
 // NewBundle returns an OSC Bundle. Use this function to create a new OSC
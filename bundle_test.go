@@ -1,8 +1,6 @@
 package osc
 
 import (
-	"bufio"
-	"bytes"
 	"testing"
 	"time"
 
@@ -12,18 +10,17 @@ import (
 func TestBundle(t *testing.T) {
 	bundle := NewBundle(time.Now())
 
-	err := bundle.Append(NewMessage("/a", "test"))
+	err := bundle.Append(mustMessage("/a", "test"))
 	assert.Nil(t, err)
-	err = bundle.Append(NewMessage("/b", "test2"))
+	err = bundle.Append(mustMessage("/b", "test2"))
 	assert.Nil(t, err)
 
 	d, err := bundle.MarshalBinary()
 	assert.Nil(t, err)
 
 	t.Run("should read bundle without padding", func(t *testing.T) {
-		io := bufio.NewReader(bytes.NewReader(d))
-		start := 0
-		b, err := readBundle(io, &start, len(d))
+		pkt, err := ParsePacket(d)
+		b, _ := pkt.(*Bundle)
 
 		assert.Nil(t, err)
 		assert.Equal(t, 2, len(b.Messages))
@@ -33,9 +30,8 @@ func TestBundle(t *testing.T) {
 
 		d1 := append(d, 0, 0, 0, 0)
 
-		io := bufio.NewReader(bytes.NewReader(d1))
-		start := 0
-		b, err := readBundle(io, &start, len(d1))
+		pkt, err := ParsePacket(d1)
+		b, _ := pkt.(*Bundle)
 
 		assert.Nil(t, err)
 		assert.Equal(t, 2, len(b.Messages))
@@ -45,9 +41,8 @@ func TestBundle(t *testing.T) {
 
 		d1 := append(d, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
 
-		io := bufio.NewReader(bytes.NewReader(d1))
-		start := 0
-		b, err := readBundle(io, &start, len(d1))
+		pkt, err := ParsePacket(d1)
+		b, _ := pkt.(*Bundle)
 
 		assert.Nil(t, err)
 		assert.Equal(t, 2, len(b.Messages))
@@ -57,11 +52,122 @@ func TestBundle(t *testing.T) {
 
 		d1 := append(d, 0, 0, 0, 1)
 
-		io := bufio.NewReader(bytes.NewReader(d1))
-		start := 0
-		_, err := readBundle(io, &start, len(d1))
+		_, err := ParsePacket(d1)
 
 		assert.NotNil(t, err)
 	})
 
 }
+
+func TestSplitBundle(t *testing.T) {
+	tt := Timetag(42)
+
+	t.Run("should split messages in order", func(t *testing.T) {
+		var msgs []*Message
+		for i := 0; i < 10; i++ {
+			msgs = append(msgs, mustMessage("/a", int32(i)))
+		}
+
+		// Each message is 12 bytes marshalled, 16 bytes as a bundle element.
+		bundles, err := SplitMessages(tt, 64, msgs...)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(bundles))
+
+		var i int32
+		for _, b := range bundles {
+			assert.Equal(t, tt, b.Timetag)
+
+			data, err := b.MarshalBinary()
+			assert.Nil(t, err)
+			assert.LessOrEqual(t, len(data), 64)
+
+			for _, m := range b.Messages {
+				assert.Equal(t, i, m.Arguments[0])
+				i++
+			}
+		}
+		assert.Equal(t, int32(10), i)
+	})
+
+	t.Run("should split nested bundles", func(t *testing.T) {
+		nested := &Bundle{Timetag: Timetag(43)}
+		for i := 0; i < 6; i++ {
+			assert.Nil(t, nested.Append(mustMessage("/b", int32(i))))
+		}
+		b := &Bundle{Timetag: tt}
+		assert.Nil(t, b.Append(mustMessage("/a")))
+		assert.Nil(t, b.Append(nested))
+
+		bundles, err := SplitBundle(b, 100)
+		assert.Nil(t, err)
+
+		var msgs int
+		for _, b := range bundles {
+			data, err := b.MarshalBinary()
+			assert.Nil(t, err)
+			assert.LessOrEqual(t, len(data), 100)
+
+			for _, nb := range b.Bundles {
+				assert.Equal(t, Timetag(43), nb.Timetag)
+				msgs += len(nb.Messages)
+			}
+		}
+		assert.Equal(t, 6, msgs)
+	})
+
+	t.Run("should fail on oversized message", func(t *testing.T) {
+		_, err := SplitMessages(tt, 64, mustMessage("/a", make([]byte, 64)))
+		assert.ErrorIs(t, err, ErrorPacketTooLarge)
+	})
+}
+
+// encodingTestBundle returns a bundle with messages and a nested bundle.
+func encodingTestBundle() *Bundle {
+	nested := &Bundle{Timetag: Timetag(2)}
+	_ = nested.Append(encodingTestMessage())
+
+	b := &Bundle{Timetag: Timetag(1)}
+	_ = b.Append(mustMessage("/a", int32(1)))
+	_ = b.Append(encodingTestMessage())
+	_ = b.Append(nested)
+
+	return b
+}
+
+func TestBundleAppendBinary(t *testing.T) {
+	b := encodingTestBundle()
+
+	data, err := b.MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, len(data), b.Size())
+
+	pkt, err := ParsePacket(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(pkt.(*Bundle).Messages))
+	assert.Equal(t, encodingTestMessage(), pkt.(*Bundle).Bundles[0].Messages[0])
+
+	buf := make([]byte, 0, b.Size())
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = b.AppendBinary(buf[:0])
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkBundleMarshalBinary(b *testing.B) {
+	bundle := encodingTestBundle()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = bundle.MarshalBinary()
+	}
+}
+
+func BenchmarkBundleAppendBinary(b *testing.B) {
+	bundle := encodingTestBundle()
+	buf := make([]byte, 0, bundle.Size())
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		buf, _ = bundle.AppendBinary(buf[:0])
+	}
+}
@@ -1,8 +1,10 @@
 package osc
 
 import (
-	"fmt"
 	"net"
+	"strconv"
+	"sync"
+	"time"
 )
 
 // Client enables you to send OSC packets. It sends OSC messages and bundles to
@@ -11,12 +13,30 @@ type Client struct {
 	IP    string
 	Port  int
 	laddr *net.UDPAddr
+
+	// ResolveInterval controls how long a resolved remote address is cached.
+	// If zero, the remote address is resolved once and reused until IP or
+	// Port change. If greater than zero, the address is resolved again on
+	// the next Send after the interval has elapsed, which is useful for DNS
+	// names whose records change over time.
+	ResolveInterval time.Duration
+
+	// Network is the network packets are sent on: "udp" or "unixgram". If
+	// empty, "udp" is used. For "unixgram", IP is the path of the server's
+	// socket and Port is ignored, see NewUnixClient.
+	Network string
+
+	mu         sync.Mutex
+	raddr      *net.UDPAddr
+	raddrKey   string
+	resolvedAt time.Time
 }
 
 // NewClient creates a new OSC client. The Client is used to send OSC
 // messages and OSC bundles over an UDP network connection. The `ip` argument
 // specifies the IP address and `port` defines the target port where the
-// messages and bundles will be send to.
+// messages and bundles will be send to. The `ip` argument may be an IPv4 or
+// IPv6 literal (optionally with a zone, e.g. "fe80::1%eth0") or a host name.
 func NewClient(ip string, port int) *Client {
 	return &Client{
 		IP:    ip,
@@ -25,9 +45,17 @@ func NewClient(ip string, port int) *Client {
 	}
 }
 
-// SetLocalAddr sets the local address.
+// NewUnixClient creates a new OSC client sending packets to the unix domain
+// datagram socket `path`. A path starting with '@' is a name in the abstract
+// namespace (Linux only).
+func NewUnixClient(path string) *Client {
+	return &Client{IP: path, Network: "unixgram"}
+}
+
+// SetLocalAddr sets the local address. The `ip` argument accepts the same
+// forms as in NewClient.
 func (c *Client) SetLocalAddr(ip string, port int) error {
-	laddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", ip, port))
+	laddr, err := net.ResolveUDPAddr("udp", joinHostPort(ip, port))
 	if err != nil {
 		return err
 	}
@@ -37,14 +65,33 @@ func (c *Client) SetLocalAddr(ip string, port int) error {
 	return nil
 }
 
-// Send sends an OSC Bundle or an OSC Message.
-func (c *Client) Send(packet Packet) error {
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", c.IP, c.Port))
+// RemoteAddr returns the resolved address packets are sent to. The address
+// is resolved on first use and cached according to ResolveInterval.
+func (c *Client) RemoteAddr() (*net.UDPAddr, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := joinHostPort(c.IP, c.Port)
+	if c.raddr != nil && c.raddrKey == key &&
+		(c.ResolveInterval <= 0 || time.Since(c.resolvedAt) < c.ResolveInterval) {
+		return c.raddr, nil
+	}
+
+	raddr, err := net.ResolveUDPAddr("udp", key)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	conn, err := net.DialUDP("udp", c.laddr, addr)
+	c.raddr = raddr
+	c.raddrKey = key
+	c.resolvedAt = time.Now()
+
+	return raddr, nil
+}
+
+// Send sends an OSC Bundle or an OSC Message.
+func (c *Client) Send(packet Packet) error {
+	conn, err := c.dial()
 	if err != nil {
 		return err
 	}
@@ -60,3 +107,29 @@ func (c *Client) Send(packet Packet) error {
 
 	return err
 }
+
+// dial connects to the server on the client's network.
+func (c *Client) dial() (net.Conn, error) {
+	if c.Network == "unixgram" {
+		return net.DialUnix("unixgram", nil, &net.UnixAddr{Name: c.IP, Net: "unixgram"})
+	}
+
+	addr, err := c.RemoteAddr()
+	if err != nil {
+		return nil, err
+	}
+
+	return net.DialUDP("udp", c.laddr, addr)
+}
+
+// joinHostPort combines host and port into a network address. IPv6 literals
+// are enclosed in square brackets.
+func joinHostPort(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// Sender is the interface implemented by types that send OSC packets, such
+// as Client and ServerAndClient.
+type Sender interface {
+	Send(packet Packet) error
+}
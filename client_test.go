@@ -1,6 +1,9 @@
 package osc
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestClientSetLocalAddr(t *testing.T) {
 	client := NewClient("localhost", 8967)
@@ -13,3 +16,56 @@ func TestClientSetLocalAddr(t *testing.T) {
 		t.Errorf("Expected laddr to be %s but was %s", expectedAddr, client.laddr.String())
 	}
 }
+
+func TestClientIPv6(t *testing.T) {
+	client := NewClient("::1", 8967)
+	raddr, err := client.RemoteAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := raddr.String(), "[::1]:8967"; got != want {
+		t.Errorf("Expected raddr to be %s but was %s", want, got)
+	}
+
+	if err := client.SetLocalAddr("::1", 41789); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := client.laddr.String(), "[::1]:41789"; got != want {
+		t.Errorf("Expected laddr to be %s but was %s", want, got)
+	}
+}
+
+func TestClientRemoteAddrCache(t *testing.T) {
+	client := NewClient("127.0.0.1", 8967)
+	first, err := client.RemoteAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := client.RemoteAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("Expected the cached remote address to be reused")
+	}
+
+	client.Port = 8968
+	third, err := client.RemoteAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := third.String(), "127.0.0.1:8968"; got != want {
+		t.Errorf("Expected raddr to be %s but was %s", want, got)
+	}
+
+	client.ResolveInterval = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	fourth, err := client.RemoteAddr()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == fourth {
+		t.Error("Expected the remote address to be resolved again")
+	}
+}
@@ -0,0 +1,370 @@
+// Command oscdump prints the OSC packets it receives.
+//
+// Usage:
+//
+//	oscdump [flags]
+//
+// Examples:
+//
+//	oscdump -addr :8000
+//	oscdump -addr :8000 -group 239.1.2.3 -match '/mixer/*'
+//	oscdump -network tcp -addr :8000 -framing size -json
+//	oscdump -network unixgram -addr /tmp/osc.sock -stats 5s
+//
+// Messages are printed in the text format of Message.String, bundles with
+// their timetags and nested elements. Datagrams that aren't valid OSC packets
+// are printed as hex dump.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/crgimenes/go-osc"
+	"github.com/crgimenes/go-osc/internal/cli"
+)
+
+func main() {
+	network := flag.String("network", "udp", "network to listen on: udp, tcp, unix or unixgram")
+	addr := flag.String("addr", ":8000", "address to listen on, the socket path for unix networks")
+	group := flag.String("group", "", "multicast group to join (udp only)")
+	iface := flag.String("iface", "", "network interface of the multicast group")
+	framing := flag.String("framing", "slip", "packet framing of stream networks: slip or size")
+	match := flag.String("match", "", "print only messages matching the OSC address pattern")
+	jsonOutput := flag.Bool("json", false, "print packets as JSON lines")
+	stats := flag.Duration("stats", 0, "print per-address message rates at this interval")
+	flag.Parse()
+
+	d, err := newDumper(os.Stdout, *match, *jsonOutput)
+	if err != nil {
+		fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *stats > 0 {
+		go func() {
+			ticker := time.NewTicker(*stats)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					d.writeStats(os.Stderr)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	switch *network {
+	case "udp", "udp4", "udp6", "unixgram":
+		err = listenPacket(ctx, d, *network, *addr, *group, *iface)
+	case "tcp", "tcp4", "tcp6", "unix":
+		err = listenStream(ctx, d, *network, *addr, *framing)
+	default:
+		err = fmt.Errorf("unsupported network %q", *network)
+	}
+
+	if *stats > 0 {
+		d.writeStats(os.Stderr)
+	}
+
+	if err != nil && ctx.Err() == nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "oscdump:", err)
+	os.Exit(1)
+}
+
+// listenPacket dumps the datagrams received on `addr`, joining the multicast
+// `group` if not empty.
+func listenPacket(ctx context.Context, d *dumper, network, addr, group, iface string) error {
+	var conn net.PacketConn
+	var err error
+
+	if group != "" {
+		conn, err = listenMulticast(network, addr, group, iface)
+	} else {
+		conn, err = net.ListenPacket(network, addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if network == "unixgram" {
+		defer os.Remove(addr)
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	fmt.Fprintln(os.Stderr, "oscdump: listening on", network, conn.LocalAddr())
+
+	buf := make([]byte, 65535)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		d.datagram(buf[:n], from, time.Now())
+	}
+}
+
+func listenMulticast(network, addr, group, iface string) (net.PacketConn, error) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	gaddr, err := net.ResolveUDPAddr(network, net.JoinHostPort(group, port))
+	if err != nil {
+		return nil, err
+	}
+
+	var ifi *net.Interface
+	if iface != "" {
+		if ifi, err = net.InterfaceByName(iface); err != nil {
+			return nil, err
+		}
+	}
+
+	return net.ListenMulticastUDP(network, ifi, gaddr)
+}
+
+// listenStream dumps the packets received over the connections accepted on
+// `addr`.
+func listenStream(ctx context.Context, d *dumper, network, addr, framing string) error {
+	f, err := cli.ParseFraming(framing)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	fmt.Fprintln(os.Stderr, "oscdump: listening on", network, ln.Addr())
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+
+			dec := osc.NewDecoder(conn, f)
+			for {
+				packet, err := dec.Decode()
+				if err != nil {
+					if !errors.Is(err, io.EOF) {
+						fmt.Fprintf(os.Stderr, "oscdump: %s: %v\n", conn.RemoteAddr(), err)
+					}
+					return
+				}
+
+				d.packet(packet, conn.RemoteAddr(), time.Now())
+			}
+		}()
+	}
+}
+
+// dumper prints packets and counts the messages per address.
+type dumper struct {
+	out    io.Writer
+	filter *regexp.Regexp
+	json   bool
+
+	mu     sync.Mutex
+	counts map[string]int
+	since  time.Time
+}
+
+func newDumper(out io.Writer, match string, jsonOutput bool) (*dumper, error) {
+	d := &dumper{out: out, json: jsonOutput, counts: make(map[string]int), since: time.Now()}
+
+	if match != "" {
+		if !strings.HasPrefix(match, "/") {
+			return nil, fmt.Errorf("invalid address pattern %q", match)
+		}
+
+		filter, err := osc.CompilePattern(match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address pattern %q: %w", match, err)
+		}
+		d.filter = filter
+	}
+
+	return d, nil
+}
+
+// datagram dumps a received datagram, which may not be a valid packet.
+func (d *dumper) datagram(data []byte, from net.Addr, t time.Time) {
+	packet, err := osc.ParsePacket(data)
+	if err != nil {
+		d.malformed(data, from, t, err)
+		return
+	}
+
+	d.packet(packet, from, t)
+}
+
+// packet prints the matching messages of the packet.
+func (d *dumper) packet(packet osc.Packet, from net.Addr, t time.Time) {
+	packet = d.match(packet)
+	if packet == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.count(packet)
+
+	if d.json {
+		data, err := json.Marshal(struct {
+			Time   time.Time  `json:"time"`
+			From   string     `json:"from"`
+			Packet osc.Packet `json:"packet"`
+		}{t, addrString(from), packet})
+		if err != nil {
+			fmt.Fprintf(d.out, "{\"time\":%q,\"from\":%q,\"error\":%q}\n", t.Format(time.RFC3339Nano), addrString(from), err)
+			return
+		}
+
+		fmt.Fprintf(d.out, "%s\n", data)
+		return
+	}
+
+	fmt.Fprintf(d.out, "%s %s %s\n", t.Format("15:04:05.000000"), addrString(from), packet)
+}
+
+func (d *dumper) malformed(data []byte, from net.Addr, t time.Time, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.json {
+		out, _ := json.Marshal(struct {
+			Time  time.Time `json:"time"`
+			From  string    `json:"from"`
+			Error string    `json:"error"`
+			Data  []byte    `json:"data"`
+		}{t, addrString(from), err.Error(), data})
+		fmt.Fprintf(d.out, "%s\n", out)
+		return
+	}
+
+	fmt.Fprintf(d.out, "%s %s malformed packet (%v):\n%s", t.Format("15:04:05.000000"), addrString(from), err, hex.Dump(data))
+}
+
+// match returns the packet with only the messages matching the filter, or
+// nil.
+func (d *dumper) match(packet osc.Packet) osc.Packet {
+	if d.filter == nil {
+		return packet
+	}
+
+	switch p := packet.(type) {
+	case *osc.Message:
+		if d.filter.MatchString(p.Address) {
+			return p
+		}
+
+	case *osc.Bundle:
+		b := &osc.Bundle{Timetag: p.Timetag, Messages: []*osc.Message{}, Bundles: []*osc.Bundle{}}
+
+		for _, m := range p.Messages {
+			if d.filter.MatchString(m.Address) {
+				b.Messages = append(b.Messages, m)
+			}
+		}
+
+		for _, nb := range p.Bundles {
+			if m := d.match(nb); m != nil {
+				b.Bundles = append(b.Bundles, m.(*osc.Bundle))
+			}
+		}
+
+		if len(b.Messages) > 0 || len(b.Bundles) > 0 {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// count counts the messages of the packet. d.mu must be held.
+func (d *dumper) count(packet osc.Packet) {
+	switch p := packet.(type) {
+	case *osc.Message:
+		d.counts[p.Address]++
+
+	case *osc.Bundle:
+		for _, m := range p.Messages {
+			d.count(m)
+		}
+		for _, b := range p.Bundles {
+			d.count(b)
+		}
+	}
+}
+
+// writeStats writes the message rates per address since the last call.
+func (d *dumper) writeStats(w io.Writer) {
+	d.mu.Lock()
+	counts, since := d.counts, d.since
+	d.counts, d.since = make(map[string]int), time.Now()
+	d.mu.Unlock()
+
+	elapsed := time.Since(since).Seconds()
+
+	addrs := make([]string, 0, len(counts))
+	for addr := range counts {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	fmt.Fprintf(w, "--- %d addresses in %.1fs\n", len(addrs), elapsed)
+	for _, addr := range addrs {
+		fmt.Fprintf(w, "%8d %10.1f/s  %s\n", counts[addr], float64(counts[addr])/elapsed, addr)
+	}
+}
+
+func addrString(addr net.Addr) string {
+	if addr == nil || addr.String() == "" {
+		return "-"
+	}
+
+	return addr.String()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crgimenes/go-osc"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFrom = &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}
+
+func TestDumper(t *testing.T) {
+	var out bytes.Buffer
+	d, err := newDumper(&out, "/mixer/*", false)
+	assert.Nil(t, err)
+
+	tm := time.Date(2024, 1, 2, 15, 4, 5, 123456000, time.Local)
+
+	msg, _ := osc.NewMessage("/mixer/fader", float32(0.5))
+	data, _ := msg.MarshalBinary()
+	d.datagram(data, testFrom, tm)
+	assert.Equal(t, "15:04:05.123456 127.0.0.1:5000 /mixer/fader ,f 0.5\n", out.String())
+
+	// Bundles keep the matching messages only
+	out.Reset()
+	bundle := &osc.Bundle{Timetag: 1}
+	assert.Nil(t, bundle.Append(msg))
+	other, _ := osc.NewMessage("/lights/dim", int32(1))
+	assert.Nil(t, bundle.Append(other))
+	d.packet(bundle, nil, tm)
+	assert.Equal(t, "15:04:05.123456 - #bundle 1 {\n\t/mixer/fader ,f 0.5\n}\n", out.String())
+
+	out.Reset()
+	d.packet(other, testFrom, tm)
+	assert.Empty(t, out.String())
+
+	// Malformed datagrams are dumped
+	d.datagram([]byte("/bad"), testFrom, tm)
+	assert.True(t, strings.HasPrefix(out.String(), "15:04:05.123456 127.0.0.1:5000 malformed packet"), out.String())
+	assert.Contains(t, out.String(), "2f 62 61 64")
+
+	var stats bytes.Buffer
+	d.writeStats(&stats)
+	assert.Contains(t, stats.String(), "--- 1 addresses")
+	assert.Contains(t, stats.String(), "       2 ")
+	assert.Contains(t, stats.String(), "/mixer/fader\n")
+}
+
+func TestDumperJSON(t *testing.T) {
+	var out bytes.Buffer
+	d, err := newDumper(&out, "", true)
+	assert.Nil(t, err)
+
+	msg, _ := osc.NewMessage("/a", int32(1))
+	d.packet(msg, testFrom, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	assert.Equal(t, `{"time":"2024-01-02T15:04:05Z","from":"127.0.0.1:5000","packet":{"address":"/a","args":[{"type":"i","value":1}]}}`+"\n", out.String())
+
+	out.Reset()
+	d.datagram([]byte{1, 2}, nil, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	assert.Contains(t, out.String(), `"from":"-"`)
+	assert.Contains(t, out.String(), `"data":"AQI="`)
+}
+
+func TestDumperInvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"mixer", "/a[("} {
+		_, err := newDumper(nil, pattern, false)
+		assert.NotNil(t, err, pattern)
+	}
+}
@@ -0,0 +1,92 @@
+// Command oscplay replays a recording of oscrecord with its original timing.
+//
+// Usage:
+//
+//	oscplay [flags] recording host port
+//	oscplay [flags] -network unix|unixgram recording path
+//	oscplay [flags] -network ws recording url
+//
+// Examples:
+//
+//	oscplay rehearsal.oscrec localhost 8000
+//	oscplay -speed 2 -seek 1m30s -match '/mixer/*' rehearsal.oscrec localhost 8000
+//	oscplay -loop -network unixgram rehearsal.jsonl /tmp/osc.sock
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/crgimenes/go-osc"
+	"github.com/crgimenes/go-osc/internal/cli"
+)
+
+func main() {
+	network := flag.String("network", "udp", "network to send on: udp, tcp, unix, unixgram or ws")
+	framing := flag.String("framing", "slip", "packet framing of stream networks: slip or size")
+	speed := flag.Float64("speed", 1, "playback speed factor")
+	loop := flag.Bool("loop", false, "restart the playback at the end of the recording")
+	seek := flag.Duration("seek", 0, "position in the recording to start at")
+
+	var match []string
+	flag.Func("match", "play only messages matching the OSC address pattern (repeatable)", func(pattern string) error {
+		match = append(match, pattern)
+		return nil
+	})
+
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: oscplay [flags] recording host port")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+
+	if len(args) != cli.TargetArgs(*network)+1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := play(args[0], *network, args[1:], *framing, &osc.Player{
+		Speed: *speed,
+		Loop:  *loop,
+		Start: *seek,
+		Match: match,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, "oscplay:", err)
+		os.Exit(1)
+	}
+}
+
+func play(path, network string, target []string, framing string, player *osc.Player) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	player.Records, err = osc.ReadRecords(file)
+	file.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	sender, closeSender, err := cli.Dial(network, target, framing)
+	if err != nil {
+		return err
+	}
+	defer closeSender()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "oscplay: playing %d packets (%s)\n", len(player.Records), player.Duration())
+
+	if err := player.PlayTo(ctx, sender); err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	return nil
+}
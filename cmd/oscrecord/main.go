@@ -0,0 +1,106 @@
+// Command oscrecord records the OSC packets it receives with their source
+// addresses and receive times, e.g. for replaying them with oscplay.
+//
+// Usage:
+//
+//	oscrecord [flags]
+//
+// Examples:
+//
+//	oscrecord -addr :8000 -o rehearsal.oscrec
+//	oscrecord -addr :8000 -format json -o rehearsal.jsonl
+//	oscrecord -network unix -addr /tmp/osc.sock -framing size -o session.oscrec
+//
+// Recording stops on interrupt.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/crgimenes/go-osc"
+	"github.com/crgimenes/go-osc/internal/cli"
+)
+
+func main() {
+	network := flag.String("network", "udp", "network to listen on: udp, tcp, unix or unixgram")
+	addr := flag.String("addr", ":8000", "address to listen on, the socket path for unix networks")
+	framing := flag.String("framing", "slip", "packet framing of stream networks: slip or size")
+	output := flag.String("o", "-", "recording file, - for stdout")
+	format := flag.String("format", "binary", "recording format: binary or json")
+	flag.Parse()
+
+	if err := record(*network, *addr, *framing, *output, *format); err != nil {
+		fmt.Fprintln(os.Stderr, "oscrecord:", err)
+		os.Exit(1)
+	}
+}
+
+func record(network, addr, framing, output, format string) error {
+	var f osc.RecordFormat
+	switch format {
+	case "binary":
+		f = osc.RecordBinary
+	case "json":
+		f = osc.RecordJSON
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	recorder, err := osc.NewRecorder(w, f)
+	if err != nil {
+		return err
+	}
+
+	var listenAndServe, closeServer func() error
+
+	switch network {
+	case "udp", "udp4", "udp6", "unixgram":
+		server := &osc.Server{Network: network, Addr: addr, Dispatcher: recorder}
+		listenAndServe, closeServer = server.ListenAndServe, server.Close
+
+	case "tcp", "tcp4", "tcp6", "unix":
+		f, err := cli.ParseFraming(framing)
+		if err != nil {
+			return err
+		}
+		server := &osc.StreamServer{Network: network, Addr: addr, Framing: f, Dispatcher: recorder}
+		listenAndServe, closeServer = server.ListenAndServe, server.Close
+
+	default:
+		return fmt.Errorf("unsupported network %q", network)
+	}
+
+	interrupted := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		close(interrupted)
+		closeServer()
+	}()
+
+	fmt.Fprintln(os.Stderr, "oscrecord: recording", network, addr)
+
+	err = listenAndServe()
+
+	select {
+	case <-interrupted:
+		return nil
+	default:
+		return err
+	}
+}
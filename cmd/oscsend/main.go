@@ -0,0 +1,296 @@
+// Command oscsend sends OSC messages.
+//
+// Usage:
+//
+//	oscsend [flags] host port /address [,typetags] [args...]
+//	oscsend [flags] -network unix|unixgram path /address [,typetags] [args...]
+//	oscsend [flags] -network ws url /address [,typetags] [args...]
+//
+// With a type tag string, each argument is converted to its type: 'i' and 'h'
+// integers, 'f' and 'd' floats, 's' strings, 'b' hexadecimal bytes and 't'
+// timetags; 'T', 'F' and 'N' take no argument. Without type tags, the
+// types are inferred: decimal integers and hexadecimal ones with the prefix
+// "0x" become int32 (or int64 if too large), decimal numbers float32, "true"
+// and "false" booleans, "nil" Nil and all others, e.g. "nan" or "010.5.1",
+// strings.
+//
+// Examples:
+//
+//	oscsend localhost 8000 /synth/note ,ifs 60 0.5 piano
+//	oscsend localhost 8000 /synth/volume 0.8
+//	oscsend -delay 2s localhost 8000 /cue/go
+//	oscsend -count 0 -rate 30 localhost 8000 /ping
+//	oscsend -network tcp -framing slip localhost 8000 /status
+//	oscsend -network ws ws://localhost:8080/osc /panel/button true
+//	echo '/a ,i 1' | oscsend localhost 8000 -
+//
+// With "-" as address, packets are read line by line from stdin in the text
+// format of ParseText, e.g. "/a ,i 1" or "#bundle 1 { /a ,i 1 /b ,s x }".
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crgimenes/go-osc"
+	"github.com/crgimenes/go-osc/internal/cli"
+)
+
+func main() {
+	network := flag.String("network", "udp", "network to send on: udp, tcp, unix, unixgram or ws")
+	framing := flag.String("framing", "slip", "packet framing of stream networks: slip or size")
+	at := flag.String("at", "", "send the message in a bundle with this RFC 3339 time as timetag")
+	delay := flag.Duration("delay", 0, "send the message in a bundle with the timetag now plus delay")
+	count := flag.Int("count", 1, "number of times to send the message, 0 to repeat until interrupted")
+	rate := flag.Float64("rate", 1, "repeated sends per second")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: oscsend [flags] host port /address [,typetags] [args...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+
+	targetArgs := cli.TargetArgs(*network)
+	if len(args) < targetArgs+1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if !(*rate > 0) || *count < 0 {
+		fmt.Fprintln(os.Stderr, "oscsend: -rate must be positive and -count not negative")
+		os.Exit(2)
+	}
+
+	sender, closeSender, err := cli.Dial(*network, args[:targetArgs], *framing)
+	if err != nil {
+		fatal(err)
+	}
+	defer closeSender()
+
+	args = args[targetArgs:]
+
+	if args[0] == "-" {
+		if err := sendLines(sender, os.Stdin); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	msg, err := parseMessage(args)
+	if err != nil {
+		fatal(err)
+	}
+
+	var timetag func() osc.Timetag
+	switch {
+	case *at != "":
+		tm, err := time.Parse(time.RFC3339Nano, *at)
+		if err != nil {
+			fatal(err)
+		}
+		timetag = func() osc.Timetag { return osc.NewTimetagFromTime(tm) }
+
+	case *delay != 0:
+		timetag = func() osc.Timetag { return osc.NewTimetagFromTime(time.Now().Add(*delay)) }
+	}
+
+	interval := time.Duration(float64(time.Second) / *rate)
+
+	for i := 0; *count == 0 || i < *count; i++ {
+		if i > 0 {
+			time.Sleep(interval)
+		}
+
+		var packet osc.Packet = msg
+		if timetag != nil {
+			packet = &osc.Bundle{Timetag: timetag(), Messages: []*osc.Message{msg}}
+		}
+
+		if err := sender.Send(packet); err != nil {
+			fatal(err)
+		}
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "oscsend:", err)
+	os.Exit(1)
+}
+
+// sendLines sends the packets read line by line from `r` in the text format.
+// Empty lines are skipped.
+func sendLines(sender osc.Sender, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		packet, err := osc.ParseText(text)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		if err := sender.Send(packet); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseMessage returns the message of the command line arguments: the
+// address, an optional type tag string and the arguments.
+func parseMessage(args []string) (*osc.Message, error) {
+	if !strings.HasPrefix(args[0], "/") {
+		return nil, fmt.Errorf("invalid address %q", args[0])
+	}
+
+	msg := &osc.Message{Address: args[0]}
+	args = args[1:]
+
+	if len(args) == 0 || !strings.HasPrefix(args[0], ",") {
+		for _, arg := range args {
+			if err := msg.Append(inferArgument(arg)); err != nil {
+				return nil, err
+			}
+		}
+		return msg, nil
+	}
+
+	tags := args[0][1:]
+	args = args[1:]
+
+	for _, tag := range []byte(tags) {
+		var arg any
+
+		switch tag {
+		case 'T':
+			arg = true
+		case 'F':
+			arg = false
+		case 'N':
+			arg = nil
+		case 'I':
+			return nil, errors.New("type tag 'I' is not supported")
+
+		default:
+			if len(args) == 0 {
+				return nil, fmt.Errorf("missing argument for type tag '%c'", tag)
+			}
+
+			var err error
+			if arg, err = parseArgument(tag, args[0]); err != nil {
+				return nil, fmt.Errorf("argument %q for type tag '%c': %w", args[0], tag, err)
+			}
+			args = args[1:]
+		}
+
+		if err := msg.Append(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) > 0 {
+		return nil, fmt.Errorf("%d arguments without type tag", len(args))
+	}
+
+	return msg, nil
+}
+
+// parseArgument converts `s` to an argument of the type `tag`.
+func parseArgument(tag byte, s string) (any, error) {
+	switch tag {
+	case 'i':
+		v, err := parseInt(s, 32)
+		return int32(v), err
+
+	case 'h':
+		return parseInt(s, 64)
+
+	case 'f':
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+
+	case 'd':
+		return strconv.ParseFloat(s, 64)
+
+	case 's':
+		return s, nil
+
+	case 'b':
+		return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+
+	case 't':
+		if tm, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return osc.NewTimetagFromTime(tm), nil
+		}
+		v, err := strconv.ParseUint(s, 0, 64)
+		return osc.Timetag(v), err
+	}
+
+	return nil, errors.New("unsupported type tag")
+}
+
+// parseInt parses a decimal integer, or a hexadecimal one with the prefix
+// "0x". Leading zeros don't make it octal.
+func parseInt(s string, bitSize int) (int64, error) {
+	sign, digits := "", s
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+
+	if len(digits) > 2 && (digits[:2] == "0x" || digits[:2] == "0X") {
+		return strconv.ParseInt(sign+digits[2:], 16, bitSize)
+	}
+
+	return strconv.ParseInt(s, 10, bitSize)
+}
+
+// isDecimal returns true if `s` starts like a decimal number, e.g. "1",
+// "-0.5" or ".5", unlike the words "nan" and "inf".
+func isDecimal(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	s = strings.TrimPrefix(s, ".")
+
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+// inferArgument returns the argument for `s` without a type tag.
+func inferArgument(s string) any {
+	if v, err := parseInt(s, 64); err == nil {
+		if v >= math.MinInt32 && v <= math.MaxInt32 {
+			return int32(v)
+		}
+		return v
+	}
+
+	if isDecimal(s) && !strings.ContainsAny(s, "xX_") {
+		if v, err := strconv.ParseFloat(s, 32); err == nil {
+			return float32(v)
+		}
+	}
+
+	switch strings.ToLower(s) {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "nil":
+		return nil
+	}
+
+	return s
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/crgimenes/go-osc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMessage(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"/a"}, "/a ,"},
+		{[]string{"/a", "1", "2.5", "hi", "true", "nil", "9000000000"}, `/a ,ifsTNh 1 2.5 "hi" true Nil 9000000000`},
+		{[]string{"/a", "010", "0x10", "-0x10", "-.5", "1e3"}, "/a ,iiiff 10 16 -16 -0.5 1000"},
+		{[]string{"/a", "nan", "inf", "-Infinity", "0x1p-2", "1_000"}, `/a ,sssss "nan" "inf" "-Infinity" "0x1p-2" "1_000"`},
+		{[]string{"/a", ",ih", "010", "0x10"}, "/a ,ih 10 16"},
+		{[]string{"/a", ",ifs", "1", "2", "3"}, `/a ,ifs 1 2 "3"`},
+		{[]string{"/a", ",hdTFNb", "1", "0.5", "0a0b"}, "/a ,hdTFNb 1 0.5 true false Nil [10 11]"},
+		{[]string{"/a", ",t", "1"}, "/a ,t 1"},
+	} {
+		msg, err := parseMessage(tt.args)
+		if assert.Nil(t, err, tt.args) {
+			assert.Equal(t, tt.want, msg.String(), tt.args)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"a"},
+		{"/a", ",i"},
+		{"/a", ",i", "x"},
+		{"/a", ",i", "1", "2"},
+		{"/a", ",x", "1"},
+		{"/a", ",I"},
+	} {
+		_, err := parseMessage(args)
+		assert.NotNil(t, err, args)
+	}
+}
+
+func TestSendLines(t *testing.T) {
+	var sent []string
+	sender := senderFunc(func(packet osc.Packet) error {
+		sent = append(sent, strings.ReplaceAll(packet.(interface{ String() string }).String(), "\n", " "))
+		return nil
+	})
+
+	assert.Nil(t, sendLines(sender, strings.NewReader("/a ,i 1\n\n#bundle 1 { /b ,s x }\n")))
+	assert.Equal(t, []string{"/a ,i 1", "#bundle 1 { \t/b ,s \"x\" }"}, sent)
+
+	err := sendLines(sender, strings.NewReader("/a ,i x\n"))
+	assert.ErrorIs(t, err, osc.ErrorInvalidText)
+}
+
+type senderFunc func(packet osc.Packet) error
+
+func (f senderFunc) Send(packet osc.Packet) error {
+	return f(packet)
+}
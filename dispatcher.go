@@ -3,6 +3,7 @@ package osc
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 	"time"
 )
@@ -62,10 +63,8 @@ func (s *StandardDispatcher) AddMsgHandlerExt(addr string, handler HandlerFuncEx
 		return nil
 	}
 
-	for _, chr := range "*?,[]{}# " {
-		if strings.Contains(addr, fmt.Sprintf("%c", chr)) {
-			return ErrorOscInvalidCharacter
-		}
+	if err := validateAddress(addr); err != nil {
+		return err
 	}
 
 	if addressExists(addr, s.handlers) {
@@ -77,11 +76,35 @@ func (s *StandardDispatcher) AddMsgHandlerExt(addr string, handler HandlerFuncEx
 	return nil
 }
 
+// validateAddress returns an error if `addr` contains characters that are
+// reserved for address patterns.
+func validateAddress(addr string) error {
+	for _, chr := range "*?,[]{}# " {
+		if strings.Contains(addr, fmt.Sprintf("%c", chr)) {
+			return ErrorOscInvalidCharacter
+		}
+	}
+
+	return nil
+}
+
 // AddMsgHandler adds a new message handler (HandlerFunc) for the given OSC address.
 func (s *StandardDispatcher) AddMsgHandler(addr string, handler HandlerFunc) error {
 	return s.AddMsgHandlerExt(addr, func(msg *Message, addr net.Addr) { handler(msg) })
 }
 
+// Addresses returns the sorted OSC addresses of the registered message
+// handlers, not including the default handler.
+func (s *StandardDispatcher) Addresses() []string {
+	addrs := make([]string, 0, len(s.handlers))
+	for addr := range s.handlers {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	return addrs
+}
+
 // Dispatch dispatches OSC packets. Implements the Dispatcher interface.
 func (s *StandardDispatcher) Dispatch(packet Packet, raddr net.Addr) (err error) {
 	switch p := packet.(type) {
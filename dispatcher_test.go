@@ -81,7 +81,7 @@ func TestDispatch(t *testing.T) {
 
 		err = nil
 		for _, tt := range tc {
-			msg := NewMessage(tt.msg)
+			msg := mustMessage(tt.msg)
 			err = d.Dispatch(msg, nil)
 			if tt.err {
 				assert.NotNil(t, err, "%s: msgPath = '%s', expect error", tt.desc, tt.msg)
@@ -106,9 +106,9 @@ func TestDispatch(t *testing.T) {
 		bundle := NewBundle(time.Now())
 
 		// 1 bundle, 2 messages
-		err := bundle.Append(NewMessage(handlerName[1], ""))
+		err := bundle.Append(mustMessage(handlerName[1], ""))
 		assert.Nil(t, err)
-		err = bundle.Append(NewMessage(handlerName[2], "test2"))
+		err = bundle.Append(mustMessage(handlerName[2], "test2"))
 		assert.Nil(t, err)
 
 		err = d.Dispatch(bundle, nil)
@@ -127,7 +127,7 @@ func TestDispatch(t *testing.T) {
 		bundle3 := NewBundle(time.Now())
 		err = bundle2.Append(bundle3)
 		assert.Nil(t, err)
-		err = bundle3.Append(NewMessage(handlerName[0]))
+		err = bundle3.Append(mustMessage(handlerName[0]))
 		assert.Nil(t, err)
 
 		err = d.Dispatch(bundle2, nil)
@@ -139,13 +139,13 @@ func TestDispatch(t *testing.T) {
 		assert.True(t, b[3], "check handlerFunc %v", handlerName[3])
 
 		// bundle: test error handling
-		err = bundle.Append(NewMessage("}/"))
+		err = bundle.Append(mustMessage("}/"))
 		assert.Nil(t, err)
 		err = d.Dispatch(bundle, nil)
 		assert.NotNil(t, err)
 
 		// bundle3: test error handling
-		err = bundle3.Append(NewMessage("}/"))
+		err = bundle3.Append(mustMessage("}/"))
 		assert.Nil(t, err)
 		err = d.Dispatch(bundle2, nil)
 		assert.NotNil(t, err)
@@ -223,7 +223,7 @@ func TestServerMessageDispatching(t *testing.T) {
 		case <-start:
 			time.Sleep(500 * time.Millisecond)
 			client := NewClient("localhost", port)
-			msg := NewMessage("/address/test")
+			msg := mustMessage("/address/test")
 			msg.Append(int32(1122))
 			if err := client.Send(msg); err != nil {
 				t.Error(err)
@@ -244,3 +244,12 @@ func TestServerMessageDispatching(t *testing.T) {
 
 	done.Wait()
 }
+
+func TestDispatcherAddresses(t *testing.T) {
+	d := NewStandardDispatcher()
+	for _, addr := range []string{"/b", "/a/c", "/a", "*"} {
+		assert.Nil(t, d.AddMsgHandler(addr, func(msg *Message) {}))
+	}
+
+	assert.Equal(t, []string{"/a", "/a/c", "/b"}, d.Addresses())
+}
@@ -18,7 +18,20 @@ Features:
     's' (string), 'b' (blob / binary data), 'h' (Int64), 't' (OSC timetag),
     'd' (Double/int64), 'T' (True), 'F' (False), 'N' (Nil) types.
   - OSC bundles, including timetags
+  - Automatic conversion of Go values (int, unsigned and named types,
+    time.Time, fmt.Stringer, encoding.BinaryMarshaler) to OSC arguments
+  - Application specific argument types (see RegisterType); arguments with
+    unknown type tags are kept as RawArgument
   - Support for OSC address pattern including '*', '?', '{,}' and '[]' wildcards
+  - Text representation of messages and bundles (see ParseText)
+  - JSON representation of messages and bundles (see ParseJSON)
+  - DNS-SD service advertisement and discovery via mDNS (see Advertise and
+    Browse)
+  - WebSocket transport, one packet per binary frame (see WebSocketServer)
+  - Unix domain datagram and stream sockets (see Server.Network,
+    NewUnixClient and StreamServer)
+  - Rule based forwarding of messages (see Router)
+  - Recording and replay of sessions (see Recorder and Player)
 
 This OSC implementation uses the UDP protocol for sending and receiving
 OSC packets.
@@ -58,10 +71,10 @@ go-osc supports the following OSC address patterns:
 OSC client example:
 
 	client := osc.NewClient("localhost", 8765)
-	msg := osc.NewMessage("/osc/address")
-	msg.Append(int32(111))
-	msg.Append(true)
-	msg.Append("hello")
+	msg, err := osc.NewMessage("/osc/address", int32(111), true, "hello")
+	if err != nil {
+		log.Fatal(err)
+	}
 	client.Send(msg)
 
 OSC server example:
@@ -1,136 +1,60 @@
 package osc
 
 import (
-	"bufio"
-	"bytes"
 	"encoding/binary"
-	"fmt"
 	"strings"
 )
 
-// readBlob reads an OSC blob from the blob byte array. Padding bytes are
-// removed from the reader and not returned.
-func readBlob(reader *bufio.Reader) ([]byte, int, error) {
-	// First, get the length
-	var blobLen int32
-	if err := binary.Read(reader, binary.BigEndian, &blobLen); err != nil {
-		return nil, 0, err
-	}
-	n := 4 + int(blobLen)
-
-	if blobLen < 1 || blobLen > int32(reader.Buffered()) {
-		return nil, 0, fmt.Errorf("readBlob: invalid blob length %d", blobLen)
-	}
+// padBytesNeeded determines how many bytes are needed to fill up to the next 4
+// byte length.
+func padBytesNeeded(elementLen int) int {
+	return ((4 - (elementLen % 4)) % 4)
+}
 
-	// Read the data
-	blob := make([]byte, blobLen)
-	if _, err := reader.Read(blob); err != nil {
-		return nil, 0, err
+// appendPaddedString appends a string to `dst` as an OSC string: truncated at
+// the first null, null terminated and padded to a multiple of 4 bytes.
+func appendPaddedString(dst []byte, str string) []byte {
+	// Truncate at the first null, just in case there is more than one present
+	if nullIndex := strings.IndexByte(str, 0); nullIndex > 0 {
+		str = str[:nullIndex]
 	}
 
-	// Remove the padding bytes
-	numPadBytes := padBytesNeeded(int(blobLen))
-	if numPadBytes > 0 {
-		n += numPadBytes
-		dummy := make([]byte, numPadBytes)
-		if _, err := reader.Read(dummy); err != nil {
-			return nil, 0, err
-		}
-	}
+	dst = append(dst, str...)
+	dst = append(dst, 0)
 
-	return blob, n, nil
+	return appendPadding(dst, len(str)+1)
 }
 
-// writeBlob writes the data byte array as an OSC blob into buff. If the length
-// of data isn't 32-bit aligned, padding bytes will be added.
-func writeBlob(data []byte, buf *bytes.Buffer) (int, error) {
-	// Add the size of the blob
-	lenData := len(data)
-	err := binary.Write(buf, binary.BigEndian, int32(lenData))
-	if err != nil {
-		return 0, err
-	}
-
-	// Write the data
-	_, err = buf.Write(data)
-	if err != nil {
-		return 0, err
-	}
-
-	// Add padding bytes if necessary
-	numPadBytes := padBytesNeeded(lenData)
-	if numPadBytes > 0 {
-		padBytes := make([]byte, numPadBytes)
-		n, err := buf.Write(padBytes)
-		if err != nil {
-			return 0, err
-		}
-		numPadBytes = n
+// paddedStringSize returns the number of bytes appendPaddedString appends.
+func paddedStringSize(str string) int {
+	if nullIndex := strings.IndexByte(str, 0); nullIndex > 0 {
+		str = str[:nullIndex]
 	}
 
-	return 4 + lenData + numPadBytes, nil
+	n := len(str) + 1
+	return n + padBytesNeeded(n)
 }
 
-// readPaddedString reads a padded string from the given reader. The padding
-// bytes are removed from the reader.
-func readPaddedString(reader *bufio.Reader) (string, int, error) {
-	// Read the string from the reader
-	str, err := reader.ReadString(0)
-	if err != nil {
-		return "", 0, err
-	}
-	lenStr := len(str)
-	n := lenStr
-
-	// Remove the padding bytes (leaving the null delimiter)
-	padLen := padBytesNeeded(lenStr)
-	if padLen > 0 {
-		n += padLen
-		padBytes := make([]byte, padLen)
-		if _, err = reader.Read(padBytes); err != nil {
-			return "", 0, err
-		}
-	}
+// appendBlob appends the data byte array as an OSC blob to `dst`: its size,
+// the data and padding bytes up to a multiple of 4 bytes.
+func appendBlob(dst []byte, data []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(data)))
+	dst = append(dst, data...)
 
-	// Strip off the string delimiter
-	return str[:lenStr-1], n, nil
+	return appendPadding(dst, len(data))
 }
 
-// writePaddedString writes a string with padding bytes to the a buffer.
-// Returns, the number of written bytes and an error if any.
-func writePaddedString(str string, buf *bytes.Buffer) (int, error) {
-	// Truncate at the first null, just in case there is more than one present
-	nullIndex := strings.Index(str, "\x00")
-	if nullIndex > 0 {
-		str = str[:nullIndex]
-	}
-	// Write the string to the buffer
-	n, err := buf.WriteString(str)
-	if err != nil {
-		return 0, err
-	}
-
-	// Always write a null terminator, as we stripped it earlier if it existed
-	buf.WriteByte(0)
-	n++
+// blobSize returns the number of bytes appendBlob appends.
+func blobSize(data []byte) int {
+	return 4 + len(data) + padBytesNeeded(len(data))
+}
 
-	// Calculate the padding bytes needed and create a buffer for the padding bytes
-	numPadBytes := padBytesNeeded(n)
-	if numPadBytes > 0 {
-		padBytes := make([]byte, numPadBytes)
-		// Add the padding bytes to the buffer
-		n, err := buf.Write(padBytes)
-		if err != nil {
-			return 0, err
-		}
-		numPadBytes = n
+// appendPadding appends the padding bytes needed after an element of
+// `elementLen` bytes to `dst`.
+func appendPadding(dst []byte, elementLen int) []byte {
+	for i := padBytesNeeded(elementLen); i > 0; i-- {
+		dst = append(dst, 0)
 	}
 
-	return n + numPadBytes, nil
-}
-
-// padBytesNeeded determines how many bytes are needed to fill up to the next 4
-// byte length.
-func padBytesNeeded(elementLen int) int {
-	return ((4 - (elementLen % 4)) % 4)
+	return dst
 }
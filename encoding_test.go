@@ -1,9 +1,7 @@
 package osc
 
 import (
-	"bufio"
 	"bytes"
-	"io"
 	"reflect"
 	"testing"
 )
@@ -61,11 +59,11 @@ func TestPadBytesNeeded(t *testing.T) {
 	}
 }
 
-func TestWritePaddedString(t *testing.T) {
+func TestAppendPaddedString(t *testing.T) {
 	for _, tt := range []struct {
 		s   string // string
 		buf []byte // resulting buffer
-		n   int    // bytes expected
+		n   int    // size expected
 	}{
 		{"testString", []byte{'t', 'e', 's', 't', 'S', 't', 'r', 'i', 'n', 'g', 0, 0}, 12},
 		{"testers", []byte{'t', 'e', 's', 't', 'e', 'r', 's', 0}, 8},
@@ -77,18 +75,11 @@ func TestWritePaddedString(t *testing.T) {
 		{"tes\x00\x00\x00", []byte{'t', 'e', 's', 0}, 4},         // Even if they don't fall on a 4 byte padding boundary
 		{"", []byte{0, 0, 0, 0}, 4},                              // OSC uses null terminated strings, padded to the 4 byte boundary
 	} {
-		buf := []byte{}
-		bytesBuffer := bytes.NewBuffer(buf)
-
-		n, err := writePaddedString(tt.s, bytesBuffer)
-		if err != nil {
-			t.Errorf(err.Error())
-		}
-		if got, want := n, tt.n; got != want {
-			t.Errorf("%q: Count of bytes written don't match; got = %d, want = %d", tt.s, got, want)
+		if got, want := appendPaddedString(nil, tt.s), tt.buf; !bytes.Equal(got, want) {
+			t.Errorf("%q: Buffers don't match; got = %q, want = %q", tt.s, got, want)
 		}
-		if got, want := bytesBuffer, tt.buf; !bytes.Equal(got.Bytes(), want) {
-			t.Errorf("%q: Buffers don't match; got = %q, want = %q", tt.s, got.Bytes(), want)
+		if got, want := paddedStringSize(tt.s), tt.n; got != want {
+			t.Errorf("%q: paddedStringSize() = %d, want = %d", tt.s, got, want)
 		}
 	}
 }
@@ -104,14 +95,15 @@ func TestReadPaddedString(t *testing.T) {
 		{[]byte{'t', 'e', 's', 't', 'e', 'r', 's', 0}, 8, "testers", nil},
 		{[]byte{'t', 'e', 's', 't', 's', 0, 0, 0}, 8, "tests", nil},
 		{[]byte{'t', 'e', 's', 't', 0, 0, 0, 0}, 8, "test", nil},
-		{[]byte{}, 0, "", io.EOF},
+		{[]byte{}, 0, "", errShortPacket},
 		{[]byte{'t', 'e', 's', 0}, 4, "tes", nil},             // OSC uses null terminated strings
 		{[]byte{'t', 'e', 's', 0, 0, 0, 0, 0}, 4, "tes", nil}, // Additional nulls should be ignored
 		{[]byte{'t', 'e', 's', 0, 0, 0}, 4, "tes", nil},       // Whether or not the nulls fall on a 4 byte padding boundary
-		{[]byte{'t', 'e', 's', 't'}, 0, "", io.EOF},           // if there is no null byte at the end, it doesn't work.
+		{[]byte{'t', 'e', 's', 't'}, 0, "", errShortPacket},   // if there is no null byte at the end, it doesn't work.
 	} {
-		buf := bytes.NewBuffer(tt.buf)
-		s, n, err := readPaddedString(bufio.NewReader(buf))
+		d := decoder{data: tt.buf}
+		s, err := d.paddedString(len(tt.buf))
+		n := d.pos
 		if got, want := err, tt.e; got != want {
 			t.Errorf("%q: Unexpected error reading padded string; got = %q, want = %q", tt.s, got, want)
 		}
@@ -135,18 +127,24 @@ func TestReadBlob(t *testing.T) {
 		{"negative value", []byte{255, 255, 255, 255}, nil, 0, true},
 		{"large value", []byte{0, 1, 17, 112}, nil, 0, true},
 		{"regular value", []byte{0, 0, 0, 1, 10, 0, 0, 0}, []byte{10}, 8, false},
+		{"empty value", []byte{0, 0, 0, 0}, []byte{}, 4, false},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1, err := readBlob(bufio.NewReader(bytes.NewBuffer(tt.args)))
+			d := decoder{data: tt.args}
+			got, err := d.blob(len(tt.args))
+			got1 := 0
+			if err == nil {
+				got1 = d.pos
+			}
 			if (err != nil) != tt.wantErr {
-				t.Errorf("readBlob() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("blob() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("readBlob() got = %v, want %v", got, tt.want)
+				t.Errorf("blob() got = %v, want %v", got, tt.want)
 			}
 			if got1 != tt.want1 {
-				t.Errorf("readBlob() got1 = %v, want %v", got1, tt.want1)
+				t.Errorf("blob() got1 = %v, want %v", got1, tt.want1)
 			}
 		})
 	}
@@ -8,4 +8,7 @@ var (
 	ErrorOscAddressExists    = errors.New("OSC address exists already")
 	ErrorUnsuportedPackage   = errors.New("unsupported OSC packet type: only Bundle and Message are supported")
 	ErrorInvalidPacked       = errors.New("invalid OSC packet")
+	ErrorPacketTooLarge      = errors.New("OSC packet exceeds the maximum size")
+	ErrorInvalidFrame        = errors.New("invalid OSC stream frame")
+	ErrorInvalidAddress      = errors.New("OSC address must start with '/'")
 )
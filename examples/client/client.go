@@ -38,25 +38,24 @@ func main() {
 
 		sline := strings.TrimRight(string(line), "\n")
 		if sline == "m" {
-			message := osc.NewMessage("/message/address")
-			message.Append(int32(12345))
-			message.Append("teststring")
-			message.Append(true)
-			message.Append(false)
+			message, err := osc.NewMessage("/message/address", 12345, "teststring", true, false)
+			if err != nil {
+				fmt.Println("Error: " + err.Error())
+				os.Exit(1)
+			}
 			client.Send(message)
 		} else if sline == "b" {
 			bundle := osc.NewBundle(time.Now())
-			message1 := osc.NewMessage("/bundle/message/1")
-			message1.Append(int32(12345))
-			message1.Append("teststring")
-			message1.Append(true)
-			message1.Append(false)
-			message2 := osc.NewMessage("/bundle/message/2")
-			message2.Append(int32(3344))
-			message2.Append(float32(101.9))
-			message2.Append("string1")
-			message2.Append("string2")
-			message2.Append(true)
+			message1, err := osc.NewMessage("/bundle/message/1", 12345, "teststring", true, false)
+			if err != nil {
+				fmt.Println("Error: " + err.Error())
+				os.Exit(1)
+			}
+			message2, err := osc.NewMessage("/bundle/message/2", 3344, float32(101.9), "string1", "string2", true)
+			if err != nil {
+				fmt.Println("Error: " + err.Error())
+				os.Exit(1)
+			}
 			bundle.Append(message1)
 			bundle.Append(message2)
 			client.Send(bundle)
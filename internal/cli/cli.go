@@ -0,0 +1,76 @@
+// Package cli holds the command line handling shared by the commands.
+package cli
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/crgimenes/go-osc"
+)
+
+// ParseFraming returns the framing with the name `name` of the -framing
+// flag: "slip" or "size".
+func ParseFraming(name string) (osc.Framing, error) {
+	switch name {
+	case "slip":
+		return osc.FramingSLIP, nil
+	case "size":
+		return osc.FramingSizePrefix, nil
+	}
+
+	return 0, fmt.Errorf("unsupported framing %q", name)
+}
+
+// TargetArgs returns the number of command line arguments of a target on the
+// network: host and port for udp and tcp, a path or URL for the others.
+func TargetArgs(network string) int {
+	if network == "udp" || network == "tcp" {
+		return 2
+	}
+
+	return 1
+}
+
+// Dial returns a sender to the target on the network and a function closing
+// it. The target holds TargetArgs(network) arguments; `framing` is the name
+// of the framing of stream networks.
+func Dial(network string, target []string, framing string) (osc.Sender, func(), error) {
+	switch network {
+	case "udp":
+		port, err := strconv.Atoi(target[1])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid port %q", target[1])
+		}
+		return osc.NewClient(target[0], port), func() {}, nil
+
+	case "unixgram":
+		return osc.NewUnixClient(target[0]), func() {}, nil
+
+	case "tcp", "unix":
+		f, err := ParseFraming(framing)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		addr := target[0]
+		if network == "tcp" {
+			addr = net.JoinHostPort(target[0], target[1])
+		}
+
+		c, err := osc.DialStream(network, addr, f)
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, func() { c.Close() }, nil
+
+	case "ws":
+		c, err := osc.DialWebSocket(target[0], "")
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, func() { c.Close() }, nil
+	}
+
+	return nil, nil, fmt.Errorf("unsupported network %q", network)
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/crgimenes/go-osc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFraming(t *testing.T) {
+	f, err := ParseFraming("slip")
+	assert.Nil(t, err)
+	assert.Equal(t, osc.FramingSLIP, f)
+
+	f, err = ParseFraming("size")
+	assert.Nil(t, err)
+	assert.Equal(t, osc.FramingSizePrefix, f)
+
+	_, err = ParseFraming("none")
+	assert.NotNil(t, err)
+}
+
+func TestDial(t *testing.T) {
+	assert.Equal(t, 2, TargetArgs("udp"))
+	assert.Equal(t, 1, TargetArgs("unixgram"))
+
+	sender, closeSender, err := Dial("udp", []string{"::1", "8000"}, "")
+	assert.Nil(t, err)
+	addr, err := sender.(*osc.Client).RemoteAddr()
+	assert.Nil(t, err)
+	assert.Equal(t, "[::1]:8000", addr.String())
+	closeSender()
+
+	for _, tt := range []struct {
+		network string
+		target  []string
+		framing string
+	}{
+		{"udp", []string{"localhost", "x"}, ""},
+		{"tcp", []string{"localhost", "1"}, "none"},
+		{"sctp", []string{"localhost"}, ""},
+	} {
+		_, _, err := Dial(tt.network, tt.target, tt.framing)
+		assert.NotNil(t, err, tt)
+	}
+}
@@ -0,0 +1,422 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// PlainJSON marshals the wrapped message or bundle to JSON with arguments as
+// plain JSON values, losing their OSC types.
+type PlainJSON struct {
+	Packet Packet
+}
+
+// jsonArgument is a type tagged argument.
+type jsonArgument struct {
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+type jsonMessage struct {
+	Address string `json:"address"`
+	Args    []any  `json:"args"`
+}
+
+type jsonBundle struct {
+	Timetag  Timetag `json:"timetag"`
+	Messages []any   `json:"messages"`
+	Bundles  []any   `json:"bundles"`
+}
+
+// MarshalJSON implements the json.Marshaler interface with type tagged
+// arguments. See ParseJSON for the format.
+func (msg *Message) MarshalJSON() ([]byte, error) {
+	m, err := msg.jsonValue(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(m)
+}
+
+// MarshalJSON implements the json.Marshaler interface with type tagged
+// arguments. See ParseJSON for the format.
+func (b *Bundle) MarshalJSON() ([]byte, error) {
+	v, err := b.jsonValue(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p PlainJSON) MarshalJSON() ([]byte, error) {
+	var v any
+	var err error
+
+	switch t := p.Packet.(type) {
+	case *Message:
+		v, err = t.jsonValue(true)
+	case *Bundle:
+		v, err = t.jsonValue(true)
+	default:
+		return nil, ErrorUnsuportedPackage
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+func (msg *Message) jsonValue(plain bool) (*jsonMessage, error) {
+	m := &jsonMessage{Address: msg.Address, Args: make([]any, len(msg.Arguments))}
+
+	for i, arg := range msg.Arguments {
+		v, err := jsonArgumentValue(arg, plain)
+		if err != nil {
+			return nil, fmt.Errorf("osc: argument %d of %s: %w", i, msg.Address, err)
+		}
+		m.Args[i] = v
+	}
+
+	return m, nil
+}
+
+func (b *Bundle) jsonValue(plain bool) (*jsonBundle, error) {
+	v := &jsonBundle{
+		Timetag:  b.Timetag,
+		Messages: make([]any, len(b.Messages)),
+		Bundles:  make([]any, len(b.Bundles)),
+	}
+
+	var err error
+
+	for i, m := range b.Messages {
+		if v.Messages[i], err = m.jsonValue(plain); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, nb := range b.Bundles {
+		if v.Bundles[i], err = nb.jsonValue(plain); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// jsonArgumentValue returns the JSON value of the argument `arg`.
+func jsonArgumentValue(arg any, plain bool) (any, error) {
+	if raw, ok := arg.(RawArgument); ok {
+		if plain {
+			return raw.Data, nil
+		}
+		return jsonArgument{Type: raw.Tags, Value: raw.Data}, nil
+	}
+
+	tag := getTypeTag(arg)
+	if tag == '\xff' {
+		return nil, fmt.Errorf("unsupported type %T", arg)
+	}
+
+	var v any
+
+	switch t := arg.(type) {
+	case float32:
+		v = jsonFloat(float64(t), t)
+	case float64:
+		v = jsonFloat(t, t)
+	default:
+		v = t
+	}
+
+	if plain {
+		return v, nil
+	}
+
+	// Custom types are written as their encoding
+	if ct := customTypeOf(arg); ct != nil {
+		data, err := ct.Encode(arg)
+		if err != nil {
+			return nil, err
+		}
+		v = data
+	}
+
+	return jsonArgument{Type: string(tag), Value: v}, nil
+}
+
+// jsonFloat returns `v` unless `f` is NaN or infinite, which JSON can't
+// represent.
+func jsonFloat(f float64, v any) any {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+
+	return v
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Both type tagged
+// and plain arguments are accepted.
+func (msg *Message) UnmarshalJSON(data []byte) error {
+	var m struct {
+		Address string            `json:"address"`
+		Args    []json.RawMessage `json:"args"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+
+	msg.Address = m.Address
+	msg.Arguments = nil
+
+	for i, raw := range m.Args {
+		arg, err := decodeJSONArgument(raw)
+		if err != nil {
+			return fmt.Errorf("osc: argument %d of %s: %w", i, m.Address, err)
+		}
+		msg.Arguments = append(msg.Arguments, arg)
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (b *Bundle) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Timetag  Timetag    `json:"timetag"`
+		Messages []*Message `json:"messages"`
+		Bundles  []*Bundle  `json:"bundles"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	for i, m := range v.Messages {
+		if m == nil {
+			return fmt.Errorf("osc: message %d of bundle is null", i)
+		}
+	}
+	for i, nb := range v.Bundles {
+		if nb == nil {
+			return fmt.Errorf("osc: bundle %d of bundle is null", i)
+		}
+	}
+
+	b.Timetag = v.Timetag
+	b.Messages = append([]*Message{}, v.Messages...)
+	b.Bundles = append([]*Bundle{}, v.Bundles...)
+
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The timetag is written
+// as number.
+func (t Timetag) MarshalJSON() ([]byte, error) {
+	return strconv.AppendUint(nil, uint64(t), 10), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Besides numbers,
+// RFC 3339 time strings are accepted.
+func (t *Timetag) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var tm time.Time
+		if err := tm.UnmarshalJSON(data); err != nil {
+			return err
+		}
+
+		*t = NewTimetagFromTime(tm)
+		return nil
+	}
+
+	v, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("osc: invalid JSON timetag %s", data)
+	}
+
+	*t = Timetag(v)
+	return nil
+}
+
+// ParseJSON decodes the JSON representation of a message or bundle. Objects
+// with an "address" are messages, all others bundles.
+//
+// The JSON representation of a message is an object with the address and the
+// arguments:
+//
+//	{"address":"/a","args":[{"type":"i","value":1},{"type":"s","value":"x"}]}
+//
+// Each argument holds its type tag and value, so the types survive a round
+// trip: int64 ('h') and timetags ('t') are numbers, blobs ('b') and the
+// encoding of custom types are base64 strings and NaN and infinite floats are
+// the strings "NaN", "+Inf" and "-Inf". Arguments with unknown type tags are
+// written as the RawArgument tags and base64 data.
+//
+// A bundle is an object with the timetag and its elements:
+//
+//	{"timetag":1,"messages":[...],"bundles":[...]}
+//
+// Wrap a packet in PlainJSON to write the arguments as plain JSON values
+// instead, e.g. [1,"x"]. Both forms are accepted when unmarshalling; plain
+// numbers become int32, int64 or float64 values.
+func ParseJSON(data []byte) (Packet, error) {
+	var probe struct {
+		Address *string `json:"address"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+
+	if probe.Address != nil {
+		msg := &Message{}
+		if err := msg.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+
+	b := &Bundle{}
+	if err := b.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// decodeJSONArgument decodes a type tagged or plain argument.
+func decodeJSONArgument(raw json.RawMessage) (any, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '{' {
+		var arg struct {
+			Type  string          `json:"type"`
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &arg); err != nil {
+			return nil, err
+		}
+
+		return decodeTypedJSON(arg.Type, arg.Value)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	switch t := v.(type) {
+	case nil, bool, string:
+		return t, nil
+
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			if i >= math.MinInt32 && i <= math.MaxInt32 {
+				return int32(i), nil
+			}
+			return i, nil
+		}
+		return t.Float64()
+	}
+
+	return nil, fmt.Errorf("unsupported JSON value %s", raw)
+}
+
+// decodeTypedJSON decodes the JSON value `value` of an argument with the type
+// tags `tags`.
+func decodeTypedJSON(tags string, value json.RawMessage) (any, error) {
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("missing argument type")
+	}
+
+	var err error
+
+	if len(tags) == 1 {
+		switch tags[0] {
+		case 'i':
+			var v int32
+			err = json.Unmarshal(value, &v)
+			return v, err
+
+		case 'h':
+			var v int64
+			err = json.Unmarshal(value, &v)
+			return v, err
+
+		case 'f':
+			v, err := decodeJSONFloat(value, 32)
+			return float32(v), err
+
+		case 'd':
+			return decodeJSONFloat(value, 64)
+
+		case 's':
+			var v string
+			err = json.Unmarshal(value, &v)
+			return v, err
+
+		case 'b':
+			v := []byte{}
+			err = json.Unmarshal(value, &v)
+			return v, err
+
+		case 't':
+			var v Timetag
+			err = json.Unmarshal(value, &v)
+			return v, err
+
+		case 'T':
+			return true, nil
+
+		case 'F':
+			return false, nil
+
+		case 'N':
+			return nil, nil
+		}
+	}
+
+	var data []byte
+	if err = json.Unmarshal(value, &data); err != nil {
+		return nil, err
+	}
+
+	if ct := customTypeByTag(tags[0]); ct != nil && len(tags) == 1 {
+		v, _, err := ct.Decode(data)
+		return v, err
+	}
+
+	return RawArgument{Tags: tags, Data: data}, nil
+}
+
+// decodeJSONFloat decodes a JSON number or one of the strings "NaN", "+Inf"
+// and "-Inf".
+func decodeJSONFloat(value json.RawMessage, bitSize int) (float64, error) {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return strconv.ParseFloat(s, bitSize)
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(value, &n); err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(n.String(), bitSize)
+}
@@ -0,0 +1,83 @@
+package osc
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageJSON(t *testing.T) {
+	msg := mustMessage("/a", int32(1), float32(0.1), "x", []byte{1, 2}, int64(math.MaxInt64),
+		0.1, Timetag(math.MaxUint64), true, false, nil)
+
+	data, err := json.Marshal(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"address":"/a","args":[{"type":"i","value":1},{"type":"f","value":0.1},`+
+		`{"type":"s","value":"x"},{"type":"b","value":"AQI="},{"type":"h","value":9223372036854775807},`+
+		`{"type":"d","value":0.1},{"type":"t","value":18446744073709551615},{"type":"T","value":true},`+
+		`{"type":"F","value":false},{"type":"N","value":null}]}`, string(data))
+
+	var got Message
+	assert.Nil(t, json.Unmarshal(data, &got))
+	assert.Equal(t, msg, &got)
+
+	data, err = json.Marshal(PlainJSON{msg})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"address":"/a","args":[1,0.1,"x","AQI=",9223372036854775807,0.1,18446744073709551615,true,false,null]}`, string(data))
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	registerRGBA(t)
+
+	for _, pkt := range []Packet{
+		mustMessage("/"),
+		mustMessage("/floats", float32(math.NaN()), math.Inf(1), float32(math.Inf(-1)), float32(math.MaxFloat32)),
+		mustMessage("/custom", rgba{1, 2, 3, 4}),
+		mustMessage("/raw", RawArgument{Tags: "xs", Data: []byte{1, 2, 3, 4, 'a', 0, 0, 0}}),
+		parseTestBundle(),
+	} {
+		data, err := json.Marshal(pkt)
+		assert.Nil(t, err)
+
+		got, err := ParseJSON(data)
+		assert.Nil(t, err, string(data))
+
+		// NaN != NaN, so compare the binary representations
+		want, _ := pkt.MarshalBinary()
+		gotData, err := got.MarshalBinary()
+		assert.Nil(t, err)
+		assert.Equal(t, want, gotData, string(data))
+	}
+}
+
+func TestParseJSONPlain(t *testing.T) {
+	got, err := ParseJSON([]byte(`{"address":"/a","args":[1,4294967296,1.5,"x",true,null]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, mustMessage("/a", int32(1), int64(4294967296), 1.5, "x", true, nil), got)
+
+	got, err = ParseJSON([]byte(`{"timetag":"2024-01-02T03:04:05Z","messages":[{"address":"/a"}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, NewTimetagFromTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)), got.(*Bundle).Timetag)
+	assert.Equal(t, []*Message{mustMessage("/a")}, got.(*Bundle).Messages)
+	assert.Equal(t, []*Bundle{}, got.(*Bundle).Bundles)
+
+	for _, data := range []string{
+		`[]`,
+		`{"address":"/a","args":[[1]]}`,
+		`{"address":"/a","args":[{"type":"i","value":1.5}]}`,
+		`{"address":"/a","args":[{"type":"i","value":4294967296}]}`,
+		`{"address":"/a","args":[{"type":"f","value":"x"}]}`,
+		`{"address":"/a","args":[{"value":1}]}`,
+		`{"timetag":-1}`,
+		`{"messages":[null]}`,
+		`{"messages":[{"address":"/a"},null]}`,
+		`{"bundles":[null]}`,
+		`{"bundles":[{"bundles":[null]}]}`,
+	} {
+		_, err := ParseJSON([]byte(data))
+		assert.NotNil(t, err, data)
+	}
+}
@@ -0,0 +1,532 @@
+package osc
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	timetagType = reflect.TypeOf(Timetag(0))
+)
+
+// structField describes how a struct field maps to an OSC message.
+type structField struct {
+	index   []int
+	name    string
+	typeTag byte // type override, or 0 to derive the type from the field
+	address bool // the field holds the OSC address
+	slice   bool // the field is a slice other than []byte
+}
+
+// Marshal returns the OSC message encoding of `v`, which must be a struct or
+// a pointer to a struct. Exported fields are encoded as arguments in the
+// order they are declared, with the OSC type derived from the Go type:
+//
+//	bool                  'T' or 'F'
+//	int, int8 ... int32   'i'
+//	int64                 'h'
+//	uint, uint8 ... uint64 'i' (values must fit into an int32)
+//	float32               'f'
+//	float64               'd'
+//	string                's'
+//	[]byte                'b'
+//	Timetag, time.Time    't'
+//	nil pointer or any    'N'
+//
+// The encoding of each field can be customized with the "osc" struct tag.
+// The tag has the form "name,option". The name is only used by the address
+// option; the option is either "address" or a type tag overriding the
+// default type, e.g. `osc:",h"` encodes an int as an int64 and `osc:",f"`
+// encodes it as a float32. A field with the tag "-" is omitted.
+//
+// A string field with the "address" option holds the OSC address of the
+// message. If it is empty or the field is the blank identifier, the tag name
+// is used instead:
+//
+//	type Fader struct {
+//		_     struct{} `osc:"/ch/01/mix/fader,address"`
+//		Level float32
+//	}
+//
+// Slices other than []byte are flattened into consecutive arguments, so a
+// slice field must be the last argument. Fields of struct type other than
+// time.Time are flattened as well; a struct type containing itself, e.g.
+// through a pointer field, is an error.
+func Marshal(v any) (*Message, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := messageFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &Message{}
+
+	for _, f := range fields {
+		if f.address {
+			if msg.Address == "" {
+				msg.Address = f.name
+			}
+			if f.index != nil {
+				if fv, err := rv.FieldByIndexErr(f.index); err == nil && fv.String() != "" {
+					msg.Address = fv.String()
+				}
+			}
+			continue
+		}
+
+		fv, err := rv.FieldByIndexErr(f.index)
+		if err != nil {
+			return nil, fmt.Errorf("osc: field %s: %w", f.name, err)
+		}
+
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			for i := 0; i < fv.Len(); i++ {
+				arg, err := encodeValue(fv.Index(i), f.typeTag)
+				if err != nil {
+					return nil, fmt.Errorf("osc: field %s: %w", f.name, err)
+				}
+				msg.Arguments = append(msg.Arguments, arg)
+			}
+			continue
+		}
+
+		arg, err := encodeValue(fv, f.typeTag)
+		if err != nil {
+			return nil, fmt.Errorf("osc: field %s: %w", f.name, err)
+		}
+		msg.Arguments = append(msg.Arguments, arg)
+	}
+
+	return msg, nil
+}
+
+// Unmarshal stores the arguments of `msg` in the struct pointed to by `v`,
+// using the field mapping described for Marshal. Integer and floating point
+// arguments are converted to the field type if the value fits. A slice field
+// other than []byte receives all remaining arguments. Surplus arguments are
+// ignored; missing arguments are an error.
+func Unmarshal(msg *Message, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("osc: Unmarshal(non-pointer %T)", v)
+	}
+
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	fields, err := messageFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	var ix int
+	for _, f := range fields {
+		if f.address {
+			if f.index != nil {
+				fieldByIndexAlloc(rv, f.index).SetString(msg.Address)
+			}
+			continue
+		}
+
+		fv := fieldByIndexAlloc(rv, f.index)
+
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			n := len(msg.Arguments) - ix
+			if n < 0 {
+				n = 0
+			}
+			s := reflect.MakeSlice(fv.Type(), n, n)
+			for i := 0; i < n; i++ {
+				if err := decodeValue(msg.Arguments[ix], s.Index(i)); err != nil {
+					return fmt.Errorf("osc: argument %d into field %s: %w", ix, f.name, err)
+				}
+				ix++
+			}
+			fv.Set(s)
+			continue
+		}
+
+		if ix >= len(msg.Arguments) {
+			return fmt.Errorf("osc: missing argument %d for field %s", ix, f.name)
+		}
+
+		if err := decodeValue(msg.Arguments[ix], fv); err != nil {
+			return fmt.Errorf("osc: argument %d into field %s: %w", ix, f.name, err)
+		}
+		ix++
+	}
+
+	return nil
+}
+
+// structValue returns the struct `v` points to or is.
+func structValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("osc: unsupported type %T, expected a struct", v)
+	}
+
+	return rv, nil
+}
+
+// messageFields returns the fields of the struct type `t` of a message. A
+// slice field takes all remaining arguments when unmarshalling, so it must be
+// the last argument.
+func messageFields(t reflect.Type) ([]structField, error) {
+	fields, err := structFields(t, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range fields {
+		if f.slice && slices.ContainsFunc(fields[i+1:], func(f structField) bool { return !f.address }) {
+			return nil, fmt.Errorf("osc: slice field %s must be the last argument", f.name)
+		}
+	}
+
+	return fields, nil
+}
+
+// structFields returns the fields of the struct type `t` in argument order.
+// The struct types being flattened are in `parents`, a struct containing
+// itself can't be flattened.
+func structFields(t reflect.Type, index []int, parents []reflect.Type) ([]structField, error) {
+	for _, p := range parents {
+		if p == t {
+			return nil, fmt.Errorf("osc: recursive struct type %s", t)
+		}
+	}
+	parents = append(parents, t)
+
+	var fields []structField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("osc")
+		if tag == "-" {
+			continue
+		}
+
+		if !sf.IsExported() && !sf.Anonymous && sf.Name != "_" {
+			continue
+		}
+
+		name, opt, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if opt == "address" {
+			f := structField{name: name, address: true}
+			if sf.Name != "_" {
+				if sf.Type.Kind() != reflect.String {
+					return nil, fmt.Errorf("osc: address field %s must be a string", sf.Name)
+				}
+				f.index = fieldIndex
+			}
+			fields = append(fields, f)
+			continue
+		}
+
+		if len(opt) > 1 {
+			return nil, fmt.Errorf("osc: invalid option %q for field %s", opt, sf.Name)
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if opt == "" && ft.Kind() == reflect.Struct && ft != timeType {
+			nested, err := structFields(ft, fieldIndex, parents)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		f := structField{index: fieldIndex, name: sf.Name}
+		f.slice = sf.Type.Kind() == reflect.Slice && sf.Type.Elem().Kind() != reflect.Uint8
+		if name != "" {
+			f.name = name
+		}
+		if opt != "" {
+			f.typeTag = opt[0]
+		}
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// fieldByIndexAlloc returns the field of `v` at `index`, allocating nil
+// embedded struct pointers on the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// encodeValue converts `v` to an OSC argument. If `tag` is not zero, the
+// argument has that OSC type.
+func encodeValue(v reflect.Value, tag byte) (any, error) {
+	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Type() {
+	case timeType:
+		if tag == 0 || tag == 't' {
+			return NewTimetagFromTime(v.Interface().(time.Time)), nil
+		}
+	case timetagType:
+		if tag == 0 || tag == 't' {
+			return Timetag(v.Uint()), nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if tag == 0 || tag == 'T' || tag == 'F' {
+			return v.Bool(), nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return encodeInt(v.Int(), v.Kind() == reflect.Int64, tag)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		if u > math.MaxInt64 {
+			return nil, fmt.Errorf("value %d out of range", u)
+		}
+		return encodeInt(int64(u), false, tag)
+
+	case reflect.Float32, reflect.Float64:
+		switch tag {
+		case 0:
+			if v.Kind() == reflect.Float32 {
+				return float32(v.Float()), nil
+			}
+			return v.Float(), nil
+		case 'f':
+			return float32(v.Float()), nil
+		case 'd':
+			return v.Float(), nil
+		}
+
+	case reflect.String:
+		switch tag {
+		case 0, 's':
+			return v.String(), nil
+		case 'b':
+			return []byte(v.String()), nil
+		}
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			switch tag {
+			case 0, 'b':
+				return v.Bytes(), nil
+			case 's':
+				return string(v.Bytes()), nil
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil, fmt.Errorf("can't encode %s as OSC type '%c'", v.Type(), tag)
+}
+
+// encodeInt converts the integer `i` to an OSC argument of the type `tag`.
+// Without a tag, `i` becomes an int64 if `wide` is set and an int32
+// otherwise.
+func encodeInt(i int64, wide bool, tag byte) (any, error) {
+	if tag == 0 {
+		tag = 'i'
+		if wide {
+			tag = 'h'
+		}
+	}
+
+	switch tag {
+	case 'i':
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("value %d out of int32 range", i)
+		}
+		return int32(i), nil
+	case 'h':
+		return i, nil
+	case 'f':
+		return float32(i), nil
+	case 'd':
+		return float64(i), nil
+	case 't':
+		return Timetag(i), nil
+	}
+
+	return nil, fmt.Errorf("can't encode integer as OSC type '%c'", tag)
+}
+
+// decodeValue stores the OSC argument `arg` in `v`.
+func decodeValue(arg any, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if arg == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(arg, v.Elem())
+
+	case reflect.Interface:
+		if arg == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		av := reflect.ValueOf(arg)
+		if !av.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("can't assign %T to %s", arg, v.Type())
+		}
+		v.Set(av)
+		return nil
+	}
+
+	switch v.Type() {
+	case timeType:
+		if tt, ok := arg.(Timetag); ok {
+			v.Set(reflect.ValueOf(tt.Time()))
+			return nil
+		}
+		return fmt.Errorf("can't assign %T to %s", arg, v.Type())
+	case timetagType:
+		if tt, ok := arg.(Timetag); ok {
+			v.SetUint(uint64(tt))
+			return nil
+		}
+		return fmt.Errorf("can't assign %T to %s", arg, v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if b, ok := arg.(bool); ok {
+			v.SetBool(b)
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := argInt(arg); ok {
+			if v.OverflowInt(i) {
+				return fmt.Errorf("value %d overflows %s", i, v.Type())
+			}
+			v.SetInt(i)
+			return nil
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := argInt(arg); ok {
+			if i < 0 || v.OverflowUint(uint64(i)) {
+				return fmt.Errorf("value %d overflows %s", i, v.Type())
+			}
+			v.SetUint(uint64(i))
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch t := arg.(type) {
+		case float32:
+			v.SetFloat(float64(t))
+			return nil
+		case float64:
+			v.SetFloat(t)
+			return nil
+		case int32:
+			v.SetFloat(float64(t))
+			return nil
+		case int64:
+			v.SetFloat(float64(t))
+			return nil
+		}
+
+	case reflect.String:
+		switch t := arg.(type) {
+		case string:
+			v.SetString(t)
+			return nil
+		case []byte:
+			v.SetString(string(t))
+			return nil
+		}
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			switch t := arg.(type) {
+			case []byte:
+				v.SetBytes(append([]byte(nil), t...))
+				return nil
+			case string:
+				v.SetBytes([]byte(t))
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("can't assign %T to %s", arg, v.Type())
+}
+
+// argInt returns the value of an int32 or int64 OSC argument, or of a
+// float32 or float64 argument without a fractional part.
+func argInt(arg any) (int64, bool) {
+	switch t := arg.(type) {
+	case int32:
+		return int64(t), true
+	case int64:
+		return t, true
+	case float32:
+		return floatInt(float64(t))
+	case float64:
+		return floatInt(t)
+	}
+
+	return 0, false
+}
+
+// floatInt returns `f` as an integer if it has no fractional part.
+func floatInt(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+
+	return int64(f), true
+}
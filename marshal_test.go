@@ -0,0 +1,159 @@
+package osc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fader struct {
+	_     struct{} `osc:"/ch/01/mix/fader,address"`
+	Level float32
+}
+
+type position struct {
+	X, Y int
+}
+
+type cue struct {
+	Address string `osc:"/cue,address"`
+	Number  int
+	Name    string
+	Go      bool
+	Offset  int `osc:",h"`
+	Gain    int `osc:",f"`
+	Skipped int `osc:"-"`
+	hidden  int
+	Pos     position
+	Data    []byte
+	When    time.Time
+	Tag     *string
+	Values  []float64
+}
+
+func TestMarshal(t *testing.T) {
+	t.Run("should use the tag address", func(t *testing.T) {
+		msg, err := Marshal(fader{Level: 0.5})
+		assert.Nil(t, err)
+		assert.Equal(t, mustMessage("/ch/01/mix/fader", float32(0.5)), msg)
+	})
+
+	t.Run("should encode all fields", func(t *testing.T) {
+		when := time.Unix(1700000000, 0)
+		c := &cue{
+			Number: 7,
+			Name:   "intro",
+			Go:     true,
+			Offset: 3,
+			Gain:   2,
+			Pos:    position{1, 2},
+			Data:   []byte{1},
+			When:   when,
+			Values: []float64{0.25, 0.5},
+		}
+
+		msg, err := Marshal(c)
+		assert.Nil(t, err)
+		assert.Equal(t, "/cue", msg.Address)
+		assert.Equal(t, ",isThfiibtNdd", msg.typeTags())
+
+		c.Address = "/cue/other"
+		msg, err = Marshal(c)
+		assert.Nil(t, err)
+		assert.Equal(t, "/cue/other", msg.Address)
+	})
+
+	t.Run("should check ranges", func(t *testing.T) {
+		_, err := Marshal(struct{ N int }{1 << 40})
+		assert.NotNil(t, err)
+
+		_, err = Marshal(struct {
+			N float32 `osc:",s"`
+		}{1})
+		assert.NotNil(t, err)
+
+		_, err = Marshal(42)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("should only flatten the last slice", func(t *testing.T) {
+		type chord struct {
+			Vel   int32
+			Notes []int32
+		}
+		msg, err := Marshal(chord{9, []int32{1, 2}})
+		assert.Nil(t, err)
+		var out chord
+		assert.Nil(t, Unmarshal(msg, &out))
+		assert.Equal(t, chord{9, []int32{1, 2}}, out)
+
+		type notes struct {
+			Notes []int32
+			Vel   int32
+		}
+		_, err = Marshal(notes{[]int32{1, 2}, 9})
+		assert.ErrorContains(t, err, "slice field Notes must be the last argument")
+		assert.NotNil(t, Unmarshal(mustMessage("/a", int32(1), int32(2), int32(9)), &notes{}))
+	})
+
+	t.Run("should reject recursive structs", func(t *testing.T) {
+		type node struct {
+			V    int32
+			Next *node
+		}
+		_, err := Marshal(node{V: 1, Next: &node{V: 2}})
+		assert.ErrorContains(t, err, "recursive struct type")
+		assert.NotNil(t, Unmarshal(mustMessage("/a", int32(1)), &node{}))
+
+		// The same struct type may appear repeatedly
+		msg, err := Marshal(struct{ A, B position }{position{1, 2}, position{3, 4}})
+		assert.Nil(t, err)
+		assert.Len(t, msg.Arguments, 4)
+	})
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Run("should round trip", func(t *testing.T) {
+		tag := "tag"
+		in := cue{
+			Address: "/cue/1",
+			Number:  7,
+			Name:    "intro",
+			Offset:  -3,
+			Gain:    2,
+			Pos:     position{1, 2},
+			Data:    []byte{1, 2},
+			When:    time.Unix(1700000000, 0),
+			Tag:     &tag,
+			Values:  []float64{0.25, 0.5},
+		}
+		msg, err := Marshal(in)
+		assert.Nil(t, err)
+
+		var out cue
+		assert.Nil(t, Unmarshal(msg, &out))
+		assert.Equal(t, "/cue/1", out.Address)
+		assert.Equal(t, in.Number, out.Number)
+		assert.Equal(t, in.Name, out.Name)
+		assert.Equal(t, in.Offset, out.Offset)
+		assert.Equal(t, in.Pos, out.Pos)
+		assert.Equal(t, in.Data, out.Data)
+		assert.True(t, in.When.Equal(out.When))
+		assert.Equal(t, "tag", *out.Tag)
+		assert.Equal(t, in.Values, out.Values)
+	})
+
+	t.Run("should fail on mismatches", func(t *testing.T) {
+		var f fader
+		assert.NotNil(t, Unmarshal(mustMessage("/a"), &f))
+		assert.NotNil(t, Unmarshal(mustMessage("/a", "x"), &f))
+		assert.NotNil(t, Unmarshal(mustMessage("/a", float32(1)), f))
+
+		var small struct{ N int8 }
+		assert.NotNil(t, Unmarshal(mustMessage("/a", int32(1000)), &small))
+		assert.NotNil(t, Unmarshal(mustMessage("/a", float32(1.5)), &small))
+		assert.Nil(t, Unmarshal(mustMessage("/a", float32(2)), &small))
+		assert.Equal(t, int8(2), small.N)
+	})
+}
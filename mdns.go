@@ -0,0 +1,775 @@
+package osc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNS-SD service types of OSC endpoints
+const (
+	ServiceTypeUDP      = "_osc._udp"
+	ServiceTypeTCP      = "_osc._tcp"
+	ServiceTypeOSCQuery = "_oscjson._tcp"
+)
+
+// DefaultServiceTTL is the time to live of advertised DNS-SD records.
+const DefaultServiceTTL = 120 * time.Second
+
+// ErrorInvalidService is returned when a service can't be advertised.
+var ErrorInvalidService = errors.New("invalid DNS-SD service")
+
+const (
+	// mdnsCacheFlush is the cache-flush bit of the class of unique records
+	// and the unicast-response bit of the class of questions.
+	mdnsCacheFlush = 1 << 15
+
+	// mdnsLegacyTTL is the maximum TTL of answers to legacy unicast queries.
+	mdnsLegacyTTL = 10 * time.Second
+
+	// mdnsServices is the name enumerating the service types of a domain.
+	mdnsServices = "_services._dns-sd._udp."
+)
+
+// mdnsGroup is the IPv4 mDNS multicast address.
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service is a DNS-SD service instance, e.g. an OSC server.
+type Service struct {
+	// Instance is the user visible name of the service, e.g. "Synth". When
+	// advertising, it must not contain dots.
+	Instance string
+
+	// Type is the service type, e.g. ServiceTypeUDP. If empty,
+	// ServiceTypeUDP is used.
+	Type string
+
+	// Domain is the domain of the service. If empty, "local." is used.
+	Domain string
+
+	// Host is the host name the service runs on, e.g. "studio.local.". If
+	// empty, the name of the machine is advertised.
+	Host string
+
+	// Port is the port of the service.
+	Port int
+
+	// Text holds the TXT record strings, usually "key=value" pairs.
+	Text []string
+
+	// Addrs are the addresses of Host. If empty, the addresses of the
+	// multicast interfaces are advertised.
+	Addrs []net.IP
+}
+
+// Name returns the full DNS name of the service instance, e.g.
+// "Synth._osc._udp.local.".
+func (s *Service) Name() string {
+	return s.Instance + "." + s.serviceName()
+}
+
+// serviceName returns the name of the service type in the domain, e.g.
+// "_osc._udp.local.".
+func (s *Service) serviceName() string {
+	typ, domain := s.Type, s.Domain
+	if typ == "" {
+		typ = ServiceTypeUDP
+	}
+	if domain == "" {
+		domain = "local."
+	}
+
+	return strings.TrimSuffix(typ, ".") + "." + strings.TrimSuffix(domain, ".") + "."
+}
+
+// TextValue returns the value of the TXT record string "key=value".
+func (s *Service) TextValue(key string) (string, bool) {
+	for _, txt := range s.Text {
+		k, v, _ := strings.Cut(txt, "=")
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// Addr returns the network address of the service, e.g. "192.168.1.2:8000".
+// IPv4 addresses are preferred; if the service has no addresses, the host
+// name is used.
+func (s *Service) Addr() string {
+	return joinHostPort(s.host(), s.Port)
+}
+
+// Client returns a Client sending to the service.
+func (s *Service) Client() *Client {
+	return NewClient(s.host(), s.Port)
+}
+
+func (s *Service) host() string {
+	for _, ip := range s.Addrs {
+		if ip.To4() != nil {
+			return ip.String()
+		}
+	}
+
+	if len(s.Addrs) > 0 {
+		return s.Addrs[0].String()
+	}
+
+	return strings.TrimSuffix(s.Host, ".")
+}
+
+// checkServiceType returns ErrorInvalidService unless `typ` is a service type
+// of the form "_name._proto", e.g. ServiceTypeUDP.
+func checkServiceType(typ string) error {
+	name, proto, ok := strings.Cut(strings.TrimSuffix(typ, "."), ".")
+	if !ok || len(name) < 2 || name[0] != '_' || len(proto) < 2 || proto[0] != '_' || strings.Contains(proto, ".") {
+		return fmt.Errorf("%w: service type %q", ErrorInvalidService, typ)
+	}
+
+	return nil
+}
+
+// Advertiser is an mDNS responder answering DNS-SD queries for a service.
+type Advertiser struct {
+	service Service
+	conn    *net.UDPConn
+
+	ptr  dnsmessage.Resource   // service type -> instance
+	meta dnsmessage.Resource   // services -> service type
+	srv  dnsmessage.Resource   // instance -> host and port
+	txt  dnsmessage.Resource   // instance -> text
+	addr []dnsmessage.Resource // host -> addresses
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Advertise announces the service via multicast DNS on the default multicast
+// interface and answers queries for it until the Advertiser is closed.
+func Advertise(service Service) (*Advertiser, error) {
+	a, err := newAdvertiser(service)
+	if err != nil {
+		return nil, err
+	}
+
+	a.conn, err = net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	a.wg.Add(2)
+	go a.serve()
+	go a.announce()
+
+	return a, nil
+}
+
+// Advertise announces the server as a ServiceTypeUDP service with the name
+// `instance` and the TXT record strings `text`. The port of the server is
+// taken from s.Addr, which must not be zero; if s.Addr has an IP address,
+// only that address is advertised.
+func (s *Server) Advertise(instance string, text ...string) (*Advertiser, error) {
+	host, port, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	service := Service{Instance: instance, Type: ServiceTypeUDP, Text: text}
+
+	if service.Port, err = strconv.Atoi(port); err != nil || service.Port == 0 {
+		return nil, fmt.Errorf("%w: server address %q has no port", ErrorInvalidService, s.Addr)
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+		service.Addrs = []net.IP{ip}
+	}
+
+	return Advertise(service)
+}
+
+// newAdvertiser returns an Advertiser with the records of `service`, without
+// a connection.
+func newAdvertiser(service Service) (*Advertiser, error) {
+	if service.Instance == "" || strings.Contains(service.Instance, ".") {
+		return nil, fmt.Errorf("%w: instance name %q", ErrorInvalidService, service.Instance)
+	}
+	if service.Port <= 0 || service.Port > 0xffff {
+		return nil, fmt.Errorf("%w: port %d", ErrorInvalidService, service.Port)
+	}
+	if service.Type != "" {
+		if err := checkServiceType(service.Type); err != nil {
+			return nil, err
+		}
+	}
+
+	if service.Host == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		hostname, _, _ = strings.Cut(hostname, ".")
+		service.Host = hostname + ".local."
+	}
+	if !strings.HasSuffix(service.Host, ".") {
+		service.Host += "."
+	}
+
+	if len(service.Addrs) == 0 {
+		addrs, err := multicastAddrs()
+		if err != nil {
+			return nil, err
+		}
+		service.Addrs = addrs
+	}
+
+	serviceName, err := dnsmessage.NewName(service.serviceName())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidService, err)
+	}
+	instanceName, err := dnsmessage.NewName(service.Name())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidService, err)
+	}
+	hostName, err := dnsmessage.NewName(service.Host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidService, err)
+	}
+	metaName, err := dnsmessage.NewName(mdnsServices + strings.SplitN(service.serviceName(), ".", 3)[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidService, err)
+	}
+
+	text := service.Text
+	if len(text) == 0 {
+		// A TXT record holds at least one string
+		text = []string{""}
+	}
+
+	a := &Advertiser{
+		service: service,
+		ptr:     mdnsResource(serviceName, dnsmessage.TypePTR, false, &dnsmessage.PTRResource{PTR: instanceName}),
+		meta:    mdnsResource(metaName, dnsmessage.TypePTR, false, &dnsmessage.PTRResource{PTR: serviceName}),
+		srv: mdnsResource(instanceName, dnsmessage.TypeSRV, true, &dnsmessage.SRVResource{
+			Port:   uint16(service.Port),
+			Target: hostName,
+		}),
+		txt:  mdnsResource(instanceName, dnsmessage.TypeTXT, true, &dnsmessage.TXTResource{TXT: text}),
+		done: make(chan struct{}),
+	}
+
+	for _, ip := range service.Addrs {
+		if ip4 := ip.To4(); ip4 != nil {
+			a.addr = append(a.addr, mdnsResource(hostName, dnsmessage.TypeA, true, &dnsmessage.AResource{A: [4]byte(ip4)}))
+		} else if ip6 := ip.To16(); ip6 != nil {
+			a.addr = append(a.addr, mdnsResource(hostName, dnsmessage.TypeAAAA, true, &dnsmessage.AAAAResource{AAAA: [16]byte(ip6)}))
+		}
+	}
+
+	return a, nil
+}
+
+// mdnsResource returns a resource record with the default TTL. Unique records
+// have the cache-flush bit set.
+func mdnsResource(name dnsmessage.Name, typ dnsmessage.Type, unique bool, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	class := dnsmessage.ClassINET
+	if unique {
+		class |= mdnsCacheFlush
+	}
+
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  name,
+			Type:  typ,
+			Class: class,
+			TTL:   uint32(DefaultServiceTTL / time.Second),
+		},
+		Body: body,
+	}
+}
+
+// multicastAddrs returns the addresses of the multicast interfaces that are
+// up, or the loopback addresses if there are none.
+func multicastAddrs() ([]net.IP, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var ips, loopback []net.IP
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+
+			switch {
+			case iface.Flags&net.FlagLoopback != 0:
+				loopback = append(loopback, ipnet.IP)
+			case iface.Flags&net.FlagMulticast != 0:
+				ips = append(ips, ipnet.IP)
+			}
+		}
+	}
+
+	if len(ips) == 0 {
+		return loopback, nil
+	}
+
+	return ips, nil
+}
+
+// Service returns the advertised service.
+func (a *Advertiser) Service() Service {
+	return a.service
+}
+
+// Close sends goodbye packets, which remove the service from the caches of
+// the peers, and stops answering queries.
+func (a *Advertiser) Close() error {
+	close(a.done)
+
+	if resp, err := a.response(a.records(0), nil, 0); err == nil {
+		_, _ = a.conn.WriteToUDP(resp, mdnsGroup)
+	}
+
+	err := a.conn.Close()
+	a.wg.Wait()
+
+	return err
+}
+
+// announce sends unsolicited responses so that peers learn about the service
+// without querying, RFC 6762 section 8.3.
+func (a *Advertiser) announce() {
+	defer a.wg.Done()
+
+	for i := 0; i < 2; i++ {
+		if resp, err := a.response(a.records(DefaultServiceTTL), nil, 0); err == nil {
+			_, _ = a.conn.WriteToUDP(resp, mdnsGroup)
+		}
+
+		select {
+		case <-time.After(time.Second):
+		case <-a.done:
+			return
+		}
+	}
+}
+
+func (a *Advertiser) serve() {
+	defer a.wg.Done()
+
+	buf := make([]byte, 9000)
+
+	for {
+		n, raddr, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-a.done:
+				return
+			default:
+			}
+
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return
+		}
+
+		resp, unicast := a.answer(buf[:n], raddr)
+		if resp == nil {
+			continue
+		}
+
+		if unicast {
+			_, _ = a.conn.WriteToUDP(resp, raddr)
+		} else {
+			_, _ = a.conn.WriteToUDP(resp, mdnsGroup)
+		}
+	}
+}
+
+// records returns all records of the service with the time to live `ttl`.
+func (a *Advertiser) records(ttl time.Duration) []dnsmessage.Resource {
+	records := append([]dnsmessage.Resource{a.ptr, a.srv, a.txt}, a.addr...)
+
+	for i := range records {
+		records[i].Header.TTL = uint32(ttl / time.Second)
+	}
+
+	return records
+}
+
+// answer returns the response to the query `data` from `raddr`, or nil if the
+// query isn't about the service. The response must be sent to `raddr` if
+// `unicast` is true, otherwise to the multicast group.
+func (a *Advertiser) answer(data []byte, raddr *net.UDPAddr) ([]byte, bool) {
+	var query dnsmessage.Message
+	if err := query.Unpack(data); err != nil || query.Header.Response {
+		return nil, false
+	}
+
+	var answers, additionals []dnsmessage.Resource
+
+	for _, q := range query.Questions {
+		if q.Class&^mdnsCacheFlush != dnsmessage.ClassINET && q.Class&^mdnsCacheFlush != dnsmessage.ClassANY {
+			continue
+		}
+
+		switch {
+		case mdnsMatch(q, a.meta):
+			answers = append(answers, a.meta)
+
+		case mdnsMatch(q, a.ptr):
+			answers = append(answers, a.ptr)
+			additionals = append(additionals, a.srv, a.txt)
+			additionals = append(additionals, a.addr...)
+
+		case mdnsMatch(q, a.srv) || mdnsMatch(q, a.txt):
+			if mdnsMatch(q, a.srv) {
+				answers = append(answers, a.srv)
+			}
+			if mdnsMatch(q, a.txt) {
+				answers = append(answers, a.txt)
+			}
+			additionals = append(additionals, a.addr...)
+
+		default:
+			for _, r := range a.addr {
+				if mdnsMatch(q, r) {
+					answers = append(answers, r)
+				}
+			}
+		}
+	}
+
+	if len(answers) == 0 {
+		return nil, false
+	}
+
+	// Queries not sent from the mDNS port are legacy unicast queries, RFC
+	// 6762 section 6.7: the answer is a conventional DNS response.
+	if raddr != nil && raddr.Port != mdnsGroup.Port {
+		resp, err := a.response(answers, &query, mdnsLegacyTTL, additionals...)
+		if err != nil {
+			return nil, false
+		}
+		return resp, true
+	}
+
+	resp, err := a.response(answers, nil, DefaultServiceTTL, additionals...)
+	if err != nil {
+		return nil, false
+	}
+
+	return resp, false
+}
+
+// response packs an mDNS response. Legacy unicast responses to `query` echo
+// its ID and questions, have a TTL of at most `ttl` and no cache-flush bits.
+func (a *Advertiser) response(answers []dnsmessage.Resource, query *dnsmessage.Message, ttl time.Duration, additionals ...dnsmessage.Resource) ([]byte, error) {
+	resp := dnsmessage.Message{
+		Header:      dnsmessage.Header{Response: true, Authoritative: true},
+		Answers:     answers,
+		Additionals: additionals,
+	}
+
+	if query != nil {
+		resp.Header.ID = query.Header.ID
+		resp.Questions = query.Questions
+		resp.Answers = legacyRecords(answers, ttl)
+		resp.Additionals = legacyRecords(additionals, ttl)
+	}
+
+	return resp.Pack()
+}
+
+// legacyRecords returns copies of the records with a TTL of at most `ttl`
+// and without cache-flush bits.
+func legacyRecords(records []dnsmessage.Resource, ttl time.Duration) []dnsmessage.Resource {
+	legacy := make([]dnsmessage.Resource, len(records))
+
+	for i, r := range records {
+		r.Header.Class &^= mdnsCacheFlush
+		if r.Header.TTL > uint32(ttl/time.Second) {
+			r.Header.TTL = uint32(ttl / time.Second)
+		}
+		legacy[i] = r
+	}
+
+	return legacy
+}
+
+// mdnsMatch returns true if the question `q` asks for the record `r`.
+func mdnsMatch(q dnsmessage.Question, r dnsmessage.Resource) bool {
+	return (q.Type == r.Header.Type || q.Type == dnsmessage.TypeALL) &&
+		strings.EqualFold(q.Name.String(), r.Header.Name.String())
+}
+
+// Browse queries the local network via multicast DNS for services of the
+// given types, e.g. ServiceTypeUDP, and returns the services found until
+// `ctx` is done. If no types are given, ServiceTypeUDP, ServiceTypeTCP and
+// ServiceTypeOSCQuery services are browsed. The queries are repeated with
+// increasing intervals while browsing.
+func Browse(ctx context.Context, serviceTypes ...string) ([]*Service, error) {
+	if len(serviceTypes) == 0 {
+		serviceTypes = []string{ServiceTypeUDP, ServiceTypeTCP, ServiceTypeOSCQuery}
+	}
+
+	b, err := newBrowser(serviceTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := b.query()
+	if err != nil {
+		return nil, err
+	}
+
+	// Responders answer queries from other ports than 5353 directly
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		buf := make([]byte, 9000)
+		for {
+			n, raddr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			b.handle(buf[:n], raddr)
+		}
+	}()
+
+	interval := time.Second
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+loop:
+	for {
+		select {
+		case <-timer.C:
+			if _, err := conn.WriteToUDP(query, mdnsGroup); err != nil {
+				conn.Close()
+				<-done
+				return nil, err
+			}
+			timer.Reset(interval)
+			interval *= 2
+
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	conn.Close()
+	<-done
+
+	return b.services(), nil
+}
+
+// browser collects the services of DNS-SD responses.
+type browser struct {
+	types []string // service type names, e.g. "_osc._udp.local."
+
+	mu        sync.Mutex
+	instances map[string]string // instance name -> service type name
+	srv       map[string]*dnsmessage.SRVResource
+	txt       map[string][]string
+	addrs     map[string][]net.IP
+	sources   map[string]net.IP // instance name -> address of the responder
+}
+
+func newBrowser(serviceTypes []string) (*browser, error) {
+	b := &browser{
+		instances: make(map[string]string),
+		srv:       make(map[string]*dnsmessage.SRVResource),
+		txt:       make(map[string][]string),
+		addrs:     make(map[string][]net.IP),
+		sources:   make(map[string]net.IP),
+	}
+
+	for _, typ := range serviceTypes {
+		if err := checkServiceType(typ); err != nil {
+			return nil, err
+		}
+		b.types = append(b.types, (&Service{Type: typ}).serviceName())
+	}
+
+	return b, nil
+}
+
+// query returns the PTR query for the service types.
+func (b *browser) query() ([]byte, error) {
+	var msg dnsmessage.Message
+
+	for _, typ := range b.types {
+		name, err := dnsmessage.NewName(typ)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorInvalidService, err)
+		}
+
+		msg.Questions = append(msg.Questions, dnsmessage.Question{
+			Name:  name,
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET,
+		})
+	}
+
+	return msg.Pack()
+}
+
+// handle collects the records of the response `data` from `raddr`.
+func (b *browser) handle(data []byte, raddr *net.UDPAddr) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(data); err != nil || !msg.Header.Response {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	records := append(msg.Answers, msg.Additionals...)
+
+	// Addresses are replaced, not merged, by the records of a response
+	seen := make(map[string]bool)
+
+	for _, r := range records {
+		name := strings.ToLower(r.Header.Name.String())
+		goodbye := r.Header.TTL == 0
+
+		switch body := r.Body.(type) {
+		case *dnsmessage.PTRResource:
+			for _, typ := range b.types {
+				if !strings.EqualFold(name, typ) {
+					continue
+				}
+
+				// Ignore instances not of the service type
+				target := body.PTR.String()
+				if len(target) <= len(typ)+1 || !strings.EqualFold(target[len(target)-len(typ)-1:], "."+typ) {
+					continue
+				}
+
+				instance := strings.ToLower(target)
+				if goodbye {
+					delete(b.instances, instance)
+					continue
+				}
+
+				// Keep the case of the instance name
+				b.instances[instance] = target[:len(target)-len(typ)] + typ
+				if raddr != nil {
+					b.sources[instance] = raddr.IP
+				}
+			}
+
+		case *dnsmessage.SRVResource:
+			if !goodbye {
+				b.srv[name] = body
+			}
+
+		case *dnsmessage.TXTResource:
+			if !goodbye {
+				b.txt[name] = body.TXT
+			}
+
+		case *dnsmessage.AResource:
+			if !seen[name] {
+				seen[name] = true
+				b.addrs[name] = nil
+			}
+			b.addrs[name] = append(b.addrs[name], net.IP(body.A[:]))
+
+		case *dnsmessage.AAAAResource:
+			if !seen[name] {
+				seen[name] = true
+				b.addrs[name] = nil
+			}
+			b.addrs[name] = append(b.addrs[name], net.IP(body.AAAA[:]))
+		}
+	}
+}
+
+// services returns the complete services found, sorted by name.
+func (b *browser) services() []*Service {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var services []*Service
+
+	for key, name := range b.instances {
+		srv, ok := b.srv[key]
+		if !ok {
+			continue
+		}
+
+		var typ string
+		for _, t := range b.types {
+			if strings.HasSuffix(name, "."+t) {
+				typ = t
+				break
+			}
+		}
+		if typ == "" {
+			continue
+		}
+
+		// The type name is "_osc._udp.local.", split into type and domain
+		parts := strings.SplitN(typ, ".", 3)
+
+		service := &Service{
+			Instance: name[:len(name)-len(typ)-1],
+			Type:     parts[0] + "." + parts[1],
+			Domain:   parts[2],
+			Host:     srv.Target.String(),
+			Port:     int(srv.Port),
+			Addrs:    b.addrs[strings.ToLower(srv.Target.String())],
+		}
+
+		for _, txt := range b.txt[key] {
+			if txt != "" {
+				service.Text = append(service.Text, txt)
+			}
+		}
+
+		if len(service.Addrs) == 0 && b.sources[key] != nil {
+			service.Addrs = []net.IP{b.sources[key]}
+		}
+
+		services = append(services, service)
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name() < services[j].Name()
+	})
+
+	return services
+}
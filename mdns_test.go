@@ -0,0 +1,166 @@
+package osc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func newTestAdvertiser(t *testing.T) *Advertiser {
+	a, err := newAdvertiser(Service{
+		Instance: "Test Synth",
+		Host:     "studio.local",
+		Port:     8765,
+		Text:     []string{"version=1"},
+		Addrs:    []net.IP{net.IPv4(192, 168, 1, 2), net.ParseIP("fe80::1")},
+	})
+	assert.Nil(t, err)
+
+	return a
+}
+
+func TestAdvertiserAnswer(t *testing.T) {
+	a := newTestAdvertiser(t)
+
+	b := must(newBrowser([]string{ServiceTypeUDP, ServiceTypeOSCQuery}))
+	query, err := b.query()
+	assert.Nil(t, err)
+
+	// Multicast query
+	resp, unicast := a.answer(query, mdnsGroup)
+	assert.False(t, unicast)
+	b.handle(resp, &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5353})
+
+	services := b.services()
+	if assert.Len(t, services, 1) {
+		s := services[0]
+		assert.Equal(t, "Test Synth._osc._udp.local.", s.Name())
+		assert.Equal(t, "studio.local.", s.Host)
+		assert.Equal(t, 8765, s.Port)
+		assert.Equal(t, []string{"version=1"}, s.Text)
+		assert.Equal(t, "192.168.1.2:8765", s.Addr())
+		assert.Equal(t, "192.168.1.2", s.Client().IP)
+
+		v, ok := s.TextValue("Version")
+		assert.True(t, ok)
+		assert.Equal(t, "1", v)
+	}
+
+	// Legacy unicast query
+	resp, unicast = a.answer(query, &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 40000})
+	assert.True(t, unicast)
+
+	var msg dnsmessage.Message
+	assert.Nil(t, msg.Unpack(resp))
+	assert.Len(t, msg.Questions, 2)
+	assert.Len(t, msg.Additionals, 4)
+	if assert.Len(t, msg.Answers, 1) {
+		assert.Equal(t, uint32(10), msg.Answers[0].Header.TTL)
+	}
+
+	// Goodbye
+	goodbye, err := a.response(a.records(0), nil, 0)
+	assert.Nil(t, err)
+	b.handle(goodbye, nil)
+	assert.Empty(t, b.services())
+}
+
+func TestAdvertiserAnswerOther(t *testing.T) {
+	a := newTestAdvertiser(t)
+
+	resp, _ := a.answer(must(must(newBrowser([]string{ServiceTypeTCP})).query()), mdnsGroup)
+	assert.Nil(t, resp)
+
+	// Service type enumeration and address queries
+	for _, q := range []dnsmessage.Question{
+		{Name: dnsmessage.MustNewName("_services._dns-sd._udp.local."), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET},
+		{Name: dnsmessage.MustNewName("STUDIO.local."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET | mdnsCacheFlush},
+	} {
+		query, err := (&dnsmessage.Message{Questions: []dnsmessage.Question{q}}).Pack()
+		assert.Nil(t, err)
+
+		resp, _ := a.answer(query, mdnsGroup)
+
+		var msg dnsmessage.Message
+		assert.Nil(t, msg.Unpack(resp))
+		assert.Len(t, msg.Answers, 1, q.Name.String())
+	}
+}
+
+func TestAdvertiseInvalid(t *testing.T) {
+	for _, s := range []Service{
+		{Instance: "", Port: 1},
+		{Instance: "a.b", Port: 1},
+		{Instance: "a", Port: 0},
+		{Instance: "a", Port: 70000},
+		{Instance: "a", Port: 1, Type: "osc"},
+		{Instance: "a", Port: 1, Type: "_osc"},
+		{Instance: "a", Port: 1, Type: "_osc._udp._x"},
+	} {
+		_, err := newAdvertiser(s)
+		assert.ErrorIs(t, err, ErrorInvalidService)
+	}
+
+	_, err := (&Server{Addr: ":0"}).Advertise("a")
+	assert.ErrorIs(t, err, ErrorInvalidService)
+}
+
+func TestBrowseInvalidType(t *testing.T) {
+	for _, typ := range []string{"", ".", "osc", "_osc", "_osc.", "_._udp"} {
+		_, err := Browse(context.Background(), typ)
+		assert.ErrorIs(t, err, ErrorInvalidService, typ)
+	}
+}
+
+func TestBrowserMalformedPTR(t *testing.T) {
+	b := must(newBrowser([]string{ServiceTypeUDP}))
+
+	msg := dnsmessage.Message{Header: dnsmessage.Header{Response: true}}
+	for _, target := range []string{"x.", "_udp.local.", "_osc._udp.local.", "a._osc._tcp.local."} {
+		msg.Answers = append(msg.Answers, mdnsResource(
+			dnsmessage.MustNewName("_osc._udp.local."), dnsmessage.TypePTR, false,
+			&dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(target)}))
+	}
+	data, err := msg.Pack()
+	assert.Nil(t, err)
+
+	assert.NotPanics(t, func() { b.handle(data, nil) })
+	assert.Empty(t, b.instances)
+	assert.Empty(t, b.services())
+}
+
+func TestAdvertiseBrowse(t *testing.T) {
+	a, err := (&Server{Addr: "127.0.0.1:8766"}).Advertise("go-osc test", "x=y")
+	if err != nil {
+		t.Skipf("multicast not available: %v", err)
+	}
+	defer a.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	services, err := Browse(ctx, ServiceTypeUDP)
+	if err != nil {
+		t.Skipf("multicast not available: %v", err)
+	}
+
+	for _, s := range services {
+		if s.Instance == "go-osc test" {
+			assert.Equal(t, "127.0.0.1:8766", s.Addr())
+			assert.Equal(t, []string{"x=y"}, s.Text)
+			return
+		}
+	}
+	t.Skip("advertised service not found, multicast loopback may be disabled")
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -1,10 +1,11 @@
 package osc
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"reflect"
+	"regexp"
 	"strings"
 )
 
@@ -21,22 +22,24 @@ type Message struct {
 // Verify that Messages implements the Packet interface.
 // var _ Packet = (*Message)(nil)
 
-// Append appends the given arguments to the arguments list.
+// Append appends the given arguments to the arguments list. Go values that
+// are not OSC types are converted: integers become int32 (or int64 for named
+// int64 types), named types use their underlying type, time.Time becomes a
+// Timetag, encoding.BinaryMarshaler a blob and fmt.Stringer a string. Types
+// implementing OSCArgument convert themselves. If any argument can't be
+// converted, an error is returned and no argument is appended.
 func (msg *Message) Append(args ...any) error {
-	// check types of args
+	converted := make([]any, len(args))
 
-	for _, arg := range args {
-		switch t := arg.(type) {
-
-		// OSC types are ok
-		case bool, int32, int64, float32, float64, string, nil, []byte, Timetag: // do nothing
-		// type is not an OSC type
-		default:
-			return fmt.Errorf("unsupported type: %T", t)
+	for i, arg := range args {
+		v, err := toArgument(arg)
+		if err != nil {
+			return err
 		}
+		converted[i] = v
 	}
 
-	msg.Arguments = append(msg.Arguments, args...)
+	msg.Arguments = append(msg.Arguments, converted...)
 	return nil
 }
 
@@ -70,6 +73,14 @@ func (msg *Message) Match(addr string) bool {
 	return regex.MatchString(addr)
 }
 
+// CompilePattern compiles the OSC address pattern `pattern`, e.g.
+// "/mixer/*/fader", to a regular expression matching the same addresses as
+// Message.Match. Unlike Match, which panics, it returns an error for invalid
+// patterns.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	return getRegEx(pattern)
+}
+
 // typeTags returns the type tag string.
 func (msg *Message) typeTags() string {
 	if len(msg.Arguments) == 0 {
@@ -80,13 +91,18 @@ func (msg *Message) typeTags() string {
 	_ = tags.WriteByte(',')
 
 	for _, m := range msg.Arguments {
+		if raw, ok := m.(RawArgument); ok {
+			tags.WriteString(raw.Tags)
+			continue
+		}
 		tags.WriteByte(getTypeTag(m))
 	}
 
 	return tags.String()
 }
 
-// String implements the fmt.Stringer interface.
+// String implements the fmt.Stringer interface. The text representation can
+// be parsed with ParseText.
 func (msg *Message) String() string {
 	if msg == nil {
 		return ""
@@ -109,6 +125,19 @@ func (msg *Message) String() string {
 
 		case Timetag:
 			s.WriteString(fmt.Sprintf(" %d", Timetag(argType)))
+
+		case RawArgument:
+			s.WriteString(fmt.Sprintf(" %d", argType.Data))
+
+		default:
+			// Custom types are written as their encoding
+			if ct := customTypeOf(argType); ct != nil {
+				if data, err := ct.Encode(argType); err == nil {
+					s.WriteString(fmt.Sprintf(" %d", data))
+					break
+				}
+			}
+			s.WriteString(fmt.Sprintf(" %v", argType))
 		}
 	}
 
@@ -121,122 +150,133 @@ func (msg *Message) String() string {
 // 2. OSC Type Tag String
 // 3. OSC Arguments.
 func (msg *Message) MarshalBinary() ([]byte, error) {
-	// We can start with the OSC address and add it to the buffer
-	data := new(bytes.Buffer)
+	return msg.AppendBinary(make([]byte, 0, msg.Size()))
+}
 
-	_, err := writePaddedString(msg.Address, data)
-	if err != nil {
-		return nil, err
-	}
+// AppendBinary appends the binary representation of the OSC message, as
+// returned by MarshalBinary, to `dst` and returns the extended buffer. It
+// doesn't allocate if `dst` has room for Size more bytes, unless the message
+// holds custom type arguments.
+func (msg *Message) AppendBinary(dst []byte) ([]byte, error) {
+	dst = appendPaddedString(dst, msg.Address)
 
 	// Type tag string starts with ","
-	lenArgs := len(msg.Arguments)
-	typetags := make([]byte, lenArgs+1)
-	typetags[0] = ','
+	tagsStart := len(dst)
+	dst = append(dst, ',')
 
-	// Process the type tags and collect all arguments
-	payload := new(bytes.Buffer)
-
-	for i, arg := range msg.Arguments {
+	for _, arg := range msg.Arguments {
 		switch t := arg.(type) {
-		case bool:
-			if t {
-				typetags[i+1] = 'T'
-				continue
+		case RawArgument:
+			dst = append(dst, t.Tags...)
+		default:
+			tag := getTypeTag(t)
+			if tag == '\xff' {
+				return nil, fmt.Errorf("unsupported type: %T", t)
 			}
+			dst = append(dst, tag)
+		}
+	}
 
-			typetags[i+1] = 'F'
+	dst = append(dst, 0)
+	dst = appendPadding(dst, len(dst)-tagsStart)
 
-		case nil:
-			typetags[i+1] = 'N'
+	// Append the payload (OSC arguments)
+	for _, arg := range msg.Arguments {
+		switch t := arg.(type) {
+		case bool, nil:
+			// No payload
 
 		case int32:
-			typetags[i+1] = 'i'
-
-			err = binary.Write(payload, binary.BigEndian, t)
-			if err != nil {
-				return nil, err
-			}
+			dst = binary.BigEndian.AppendUint32(dst, uint32(t))
 
 		case float32:
-			typetags[i+1] = 'f'
-
-			err := binary.Write(payload, binary.BigEndian, t)
-			if err != nil {
-				return nil, err
-			}
+			dst = binary.BigEndian.AppendUint32(dst, math.Float32bits(t))
 
 		case string:
-			typetags[i+1] = 's'
-
-			_, err = writePaddedString(t, payload)
-			if err != nil {
-				return nil, err
-			}
+			dst = appendPaddedString(dst, t)
 
 		case []byte:
-			typetags[i+1] = 'b'
-
-			_, err = writeBlob(t, payload)
-			if err != nil {
-				return nil, err
-			}
+			dst = appendBlob(dst, t)
 
 		case int64:
-			typetags[i+1] = 'h'
-			err = binary.Write(payload, binary.BigEndian, t)
-			if err != nil {
-				return nil, err
-			}
+			dst = binary.BigEndian.AppendUint64(dst, uint64(t))
 
 		case float64:
-			typetags[i+1] = 'd'
-
-			err = binary.Write(payload, binary.BigEndian, t)
-			if err != nil {
-				return nil, err
-			}
+			dst = binary.BigEndian.AppendUint64(dst, math.Float64bits(t))
 
 		case Timetag:
-			typetags[i+1] = 't'
+			dst, _ = t.AppendBinary(dst)
 
-			b, err := t.MarshalBinary()
-			if err != nil {
-				return nil, err
-			}
+		case RawArgument:
+			dst = append(dst, t.Data...)
 
-			_, err = payload.Write(b)
+		default:
+			b, err := customTypeOf(t).Encode(t)
 			if err != nil {
 				return nil, err
 			}
-		default:
-			return nil, fmt.Errorf("unsupported type: %T", t)
+
+			dst = append(dst, b...)
+			dst = appendPadding(dst, len(b))
 		}
 	}
 
-	// Write the type tag string to the data buffer
-	if _, err := writePaddedString(string(typetags), data); err != nil {
-		return nil, err
+	return dst, nil
+}
+
+// Size returns the number of bytes of the binary representation of the OSC
+// message. If the message holds an argument that can't be encoded, the
+// result is undefined.
+func (msg *Message) Size() int {
+	n := paddedStringSize(msg.Address)
+
+	// Type tag string with "," and the null terminator
+	tags := 2
+	for _, arg := range msg.Arguments {
+		if raw, ok := arg.(RawArgument); ok {
+			tags += len(raw.Tags)
+			continue
+		}
+		tags++
 	}
+	n += tags + padBytesNeeded(tags)
 
-	// Write the payload (OSC arguments) to the data buffer
-	if _, err := data.Write(payload.Bytes()); err != nil {
-		return nil, err
+	for _, arg := range msg.Arguments {
+		switch t := arg.(type) {
+		case bool, nil:
+		case int32, float32:
+			n += 4
+		case int64, float64, Timetag:
+			n += 8
+		case string:
+			n += paddedStringSize(t)
+		case []byte:
+			n += blobSize(t)
+		case RawArgument:
+			n += len(t.Data)
+		default:
+			if ct := customTypeOf(t); ct != nil {
+				if b, err := ct.Encode(t); err == nil {
+					n += len(b) + padBytesNeeded(len(b))
+				}
+			}
+		}
 	}
 
-	return data.Bytes(), nil
+	return n
 }
 
 // NewMessage returns a new Message. The address parameter is the OSC address.
-// if args has invalid types it return nil
-func NewMessage(addr string, args ...any) *Message {
+// The arguments are converted as described for Append; an error is returned
+// if an argument can't be converted.
+func NewMessage(addr string, args ...any) (*Message, error) {
 	msg := &Message{Address: addr}
 	err := msg.Append(args...)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return msg
+	return msg, nil
 }
 
 // Help function for argument getter
@@ -2,6 +2,7 @@ package osc
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -11,7 +12,7 @@ func TestMessage(t *testing.T) {
 	var oscAddress string = "/address"
 
 	t.Run("should append data to message", func(t *testing.T) {
-		message := NewMessage(oscAddress)
+		message := mustMessage(oscAddress)
 
 		assert.Equal(t, oscAddress, message.Address)
 
@@ -26,8 +27,8 @@ func TestMessage(t *testing.T) {
 	})
 
 	t.Run("should message equal to another message", func(t *testing.T) {
-		msg1 := NewMessage(oscAddress)
-		msg2 := NewMessage(oscAddress)
+		msg1 := mustMessage(oscAddress)
+		msg2 := mustMessage(oscAddress)
 		err := msg1.Append(int64(1234))
 		assert.Nil(t, err)
 		err = msg2.Append(int64(1234))
@@ -40,13 +41,53 @@ func TestMessage(t *testing.T) {
 		assert.True(t, msg1.Equals(msg2))
 	})
 
-	t.Run("unsuported type int throws error", func(t *testing.T) {
-		msg1 := NewMessage(oscAddress)
-		err := msg1.Append(1234)
+	t.Run("unsuported type throws error", func(t *testing.T) {
+		msg1 := mustMessage(oscAddress)
+		err := msg1.Append(int32(1), struct{}{})
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(msg1.Arguments))
+
+		_, err = NewMessage(oscAddress, make(chan int))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("should convert go types", func(t *testing.T) {
+		type level float32
+		type name string
+		ts := time.Unix(1700000000, 0)
+
+		msg1 := mustMessage(oscAddress, 1, uint8(2), level(0.5), name("n"), ts,
+			stringer{}, marshaler{}, custom{}, time.Duration(5))
+		assert.Equal(t, ArgumentsType{int32(1), int32(2), float32(0.5), "n",
+			NewTimetagFromTime(ts), "stringer", []byte{1, 2}, int32(42), int64(5)}, msg1.Arguments)
+
+		_, err := NewMessage(oscAddress, 1<<40)
 		assert.NotNil(t, err)
 	})
 }
 
+type stringer struct{}
+
+func (stringer) String() string { return "stringer" }
+
+type marshaler struct{}
+
+func (marshaler) MarshalBinary() ([]byte, error) { return []byte{1, 2}, nil }
+
+type custom struct{}
+
+func (custom) OSCArgument() (any, error) { return 42, nil }
+
+// mustMessage returns a new Message and panics if an argument can't be
+// converted.
+func mustMessage(addr string, args ...any) *Message {
+	msg, err := NewMessage(addr, args...)
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
 func TestMessage_TypeTags(t *testing.T) {
 	for _, tt := range []struct {
 		desc string
@@ -54,17 +95,17 @@ func TestMessage_TypeTags(t *testing.T) {
 		tags string
 		ok   bool
 	}{
-		{"addr_only", NewMessage("/"), ",", true},
-		{"nil", NewMessage("/", nil), ",N", true},
-		{"bool_true", NewMessage("/", true), ",T", true},
-		{"bool_false", NewMessage("/", false), ",F", true},
-		{"int32", NewMessage("/", int32(1)), ",i", true},
-		{"int64", NewMessage("/", int64(2)), ",h", true},
-		{"float32", NewMessage("/", float32(3.0)), ",f", true},
-		{"float64", NewMessage("/", float64(4.0)), ",d", true},
-		{"string", NewMessage("/", "5"), ",s", true},
-		{"[]byte", NewMessage("/", []byte{'6'}), ",b", true},
-		{"two_args", NewMessage("/", "123", int32(456)), ",si", true},
+		{"addr_only", mustMessage("/"), ",", true},
+		{"nil", mustMessage("/", nil), ",N", true},
+		{"bool_true", mustMessage("/", true), ",T", true},
+		{"bool_false", mustMessage("/", false), ",F", true},
+		{"int32", mustMessage("/", int32(1)), ",i", true},
+		{"int64", mustMessage("/", int64(2)), ",h", true},
+		{"float32", mustMessage("/", float32(3.0)), ",f", true},
+		{"float64", mustMessage("/", float64(4.0)), ",d", true},
+		{"string", mustMessage("/", "5"), ",s", true},
+		{"[]byte", mustMessage("/", []byte{'6'}), ",b", true},
+		{"two_args", mustMessage("/", "123", int32(456)), ",si", true},
 	} {
 		tags := tt.msg.typeTags()
 		if got, want := tags, tt.tags; got != want {
@@ -80,12 +121,12 @@ func TestMessage_String(t *testing.T) {
 		str  string
 	}{
 		{"nil message", nil, ""},
-		{"message with 1 nil argument", NewMessage("/foo/bar", nil), "/foo/bar ,N Nil"},
-		{"addr_only", NewMessage("/foo/bar"), "/foo/bar ,"},
-		{"one_addr", NewMessage("/foo/bar", "123"), "/foo/bar ,s \"123\""},
-		{"two_args", NewMessage("/foo/bar", "123", int32(456)), "/foo/bar ,si \"123\" 456"},
-		{"timetag", NewMessage("/foo/bar", Timetag(16818286200017484014)), "/foo/bar ,t 16818286200017484014"},
-		{"bytes", NewMessage("/foo/bar", []byte{51, 52, 53}), "/foo/bar ,b [51 52 53]"},
+		{"message with 1 nil argument", mustMessage("/foo/bar", nil), "/foo/bar ,N Nil"},
+		{"addr_only", mustMessage("/foo/bar"), "/foo/bar ,"},
+		{"one_addr", mustMessage("/foo/bar", "123"), "/foo/bar ,s \"123\""},
+		{"two_args", mustMessage("/foo/bar", "123", int32(456)), "/foo/bar ,si \"123\" 456"},
+		{"timetag", mustMessage("/foo/bar", Timetag(16818286200017484014)), "/foo/bar ,t 16818286200017484014"},
+		{"bytes", mustMessage("/foo/bar", []byte{51, 52, 53}), "/foo/bar ,b [51 52 53]"},
 	} {
 		if got, want := tt.msg.String(), tt.str; got != want {
 			t.Errorf("%s: String() = '%s', want = '%s'", tt.desc, got, want)
@@ -94,7 +135,7 @@ func TestMessage_String(t *testing.T) {
 }
 
 func TestTypeTagsString(t *testing.T) {
-	msg := NewMessage("/some/address")
+	msg := mustMessage("/some/address")
 	msg.Append(int32(100))
 	msg.Append(true)
 	msg.Append(false)
@@ -146,7 +187,7 @@ func TestOscMessageMatch(t *testing.T) {
 	}
 
 	for _, tt := range tc {
-		msg := NewMessage(tt.addr)
+		msg := mustMessage(tt.addr)
 
 		got := msg.Match(tt.addrPattern)
 		if got != tt.want {
@@ -183,7 +224,7 @@ func TestArgumentGetter(t *testing.T) {
 	var vFalse = true
 	var vNil any = true // true as dummy for not nil
 
-	msg := NewMessage("/argtest", cInt32, cInt64, cFloat32, cFloat64, cString, cTimetag, cBytes, true, false, nil)
+	msg := mustMessage("/argtest", cInt32, cInt64, cFloat32, cFloat64, cString, cTimetag, cBytes, true, false, nil)
 
 	//check values
 
@@ -265,7 +306,7 @@ func TestArgumentGetter(t *testing.T) {
 }
 
 func TestClearMessage(t *testing.T) {
-	msg := NewMessage("/msg", int32(4), "msg")
+	msg := mustMessage("/msg", int32(4), "msg")
 	assert.Equal(t, "/msg", msg.Address)
 	assert.Equal(t, 2, len(msg.Arguments))
 	msg.Clear()
@@ -274,7 +315,68 @@ func TestClearMessage(t *testing.T) {
 }
 
 func TestMatchPanic(t *testing.T) {
-	msg := NewMessage("}/")
+	msg := mustMessage("}/")
 	assert.Panics(t, func() { _ = msg.Match("/msg") })
 
 }
+
+func TestCompilePattern(t *testing.T) {
+	regex, err := CompilePattern("/mixer/*/{fader,mute}")
+	assert.Nil(t, err)
+	assert.True(t, regex.MatchString("/mixer/1/fader"))
+	assert.False(t, regex.MatchString("/mixer/1/pan"))
+
+	_, err = CompilePattern("}/")
+	assert.NotNil(t, err)
+}
+
+// encodingTestMessage returns a message with arguments of all types.
+func encodingTestMessage() *Message {
+	return mustMessage("/encoding/test", int32(1), float32(2), "three", []byte{4, 5, 6},
+		int64(7), float64(8), Timetag(9), true, false, nil)
+}
+
+func TestMessageAppendBinary(t *testing.T) {
+	for _, msg := range []*Message{
+		mustMessage("/"),
+		mustMessage("/abc", ""),
+		mustMessage("/abcd", "abcd", []byte{}),
+		mustMessage("/a", []byte{1, 2, 3, 4, 5}),
+		encodingTestMessage(),
+	} {
+		data, err := msg.MarshalBinary()
+		assert.Nil(t, err)
+		assert.Equal(t, len(data), msg.Size(), msg.String())
+
+		prefix := []byte{1, 2, 3, 4}
+		appended, err := msg.AppendBinary(prefix)
+		assert.Nil(t, err)
+		assert.Equal(t, append(prefix, data...), appended)
+	}
+
+	msg := encodingTestMessage()
+	buf := make([]byte, 0, msg.Size())
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = msg.AppendBinary(buf[:0])
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkMessageMarshalBinary(b *testing.B) {
+	msg := encodingTestMessage()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = msg.MarshalBinary()
+	}
+}
+
+func BenchmarkMessageAppendBinary(b *testing.B) {
+	msg := encodingTestMessage()
+	buf := make([]byte, 0, msg.Size())
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		buf, _ = msg.AppendBinary(buf[:0])
+	}
+}
@@ -0,0 +1,435 @@
+package osc
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// QueryAccess is the ACCESS attribute of an OSCQuery node.
+type QueryAccess int
+
+// OSCQuery access values
+const (
+	QueryAccessNone QueryAccess = iota
+	QueryAccessRead
+	QueryAccessWrite
+	QueryAccessReadWrite
+)
+
+// QueryRange is an element of the RANGE attribute of an OSCQuery node: the
+// minimum and maximum or the allowed values of an argument.
+type QueryRange struct {
+	Min  any   `json:"MIN,omitempty"`
+	Max  any   `json:"MAX,omitempty"`
+	Vals []any `json:"VALS,omitempty"`
+}
+
+// QueryNode is a node of an OSCQuery namespace. Containers have Contents,
+// OSC methods have a Type.
+type QueryNode struct {
+	FullPath    string                `json:"FULL_PATH"`
+	Contents    map[string]*QueryNode `json:"CONTENTS,omitempty"`
+	Type        string                `json:"TYPE,omitempty"`
+	Access      QueryAccess           `json:"ACCESS,omitempty"`
+	Value       []any                 `json:"VALUE,omitempty"`
+	Range       []QueryRange          `json:"RANGE,omitempty"`
+	Description string                `json:"DESCRIPTION,omitempty"`
+}
+
+// QueryHostInfo is the answer to a HOST_INFO query.
+type QueryHostInfo struct {
+	Name         string          `json:"NAME,omitempty"`
+	Extensions   map[string]bool `json:"EXTENSIONS"`
+	OSCIP        string          `json:"OSC_IP,omitempty"`
+	OSCPort      int             `json:"OSC_PORT,omitempty"`
+	OSCTransport string          `json:"OSC_TRANSPORT,omitempty"`
+}
+
+// QueryMetadata describes an OSC address for OSCQuery.
+type QueryMetadata struct {
+	// TypeTags are the type tags of the arguments, e.g. "ff". A leading ','
+	// is removed.
+	TypeTags string
+
+	// Range holds one QueryRange per argument.
+	Range []QueryRange
+
+	// Description is a human readable description of the address.
+	Description string
+
+	// Access of the address. If zero, it is QueryAccessWrite for addresses
+	// with a handler plus QueryAccessRead for addresses with a value.
+	Access QueryAccess
+}
+
+// QueryServer is an OSCQuery HTTP server describing the address space of a
+// StandardDispatcher. Use it as the Dispatcher of the OSC server to serve the
+// last received arguments of each address as its VALUE.
+type QueryServer struct {
+	// Name is the NAME reported by HOST_INFO.
+	Name string
+
+	// OSCHost and OSCPort are the OSC_IP and OSC_PORT reported by HOST_INFO.
+	// Clients use the host of the HTTP server if OSCHost is empty.
+	OSCHost string
+	OSCPort int
+
+	dispatcher *StandardDispatcher
+	mu         sync.RWMutex
+	metadata   map[string]QueryMetadata
+	values     map[string]ArgumentsType
+	listeners  map[*queryListener]struct{}
+}
+
+// queryAttributes are the supported OSCQuery attributes and extensions.
+var queryAttributes = []string{"FULL_PATH", "CONTENTS", "TYPE", "ACCESS", "VALUE", "RANGE", "DESCRIPTION", "LISTEN"}
+
+// NewQueryServer returns a QueryServer for the handlers of `dispatcher`.
+func NewQueryServer(dispatcher *StandardDispatcher) *QueryServer {
+	return &QueryServer{
+		dispatcher: dispatcher,
+		metadata:   make(map[string]QueryMetadata),
+		values:     make(map[string]ArgumentsType),
+		listeners:  make(map[*queryListener]struct{}),
+	}
+}
+
+// Describe sets the metadata of the OSC address `addr`. Addresses don't need
+// a handler to be described.
+func (q *QueryServer) Describe(addr string, meta QueryMetadata) error {
+	if err := validateQueryAddress(addr); err != nil {
+		return err
+	}
+
+	meta.TypeTags = strings.TrimPrefix(meta.TypeTags, ",")
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.metadata[addr] = meta
+
+	return nil
+}
+
+// SetValue sets the value of the OSC address `addr`. The arguments are
+// converted as described for Message.Append.
+func (q *QueryServer) SetValue(addr string, args ...any) error {
+	if err := validateQueryAddress(addr); err != nil {
+		return err
+	}
+
+	msg, err := NewMessage(addr, args...)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.values[addr] = msg.Arguments
+	q.notify(addr, msg.Arguments)
+
+	return nil
+}
+
+// Value returns the value of the OSC address `addr`, or nil.
+func (q *QueryServer) Value(addr string) ArgumentsType {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	return q.values[addr]
+}
+
+// Dispatch dispatches the packet to the dispatcher of the query server and
+// stores the arguments of the messages as values of the matching addresses.
+// Implements the Dispatcher interface.
+func (q *QueryServer) Dispatch(packet Packet, raddr net.Addr) error {
+	if err := q.dispatcher.Dispatch(packet, raddr); err != nil {
+		return err
+	}
+
+	return q.storeValues(packet)
+}
+
+func (q *QueryServer) storeValues(packet Packet) error {
+	switch p := packet.(type) {
+	case *Message:
+		regex, err := getRegEx(p.Address)
+		if err != nil {
+			return err
+		}
+
+		var args ArgumentsType
+
+		for _, addr := range q.addresses() {
+			if !regex.MatchString(addr) {
+				continue
+			}
+
+			if args == nil {
+				args = p.Clone().Arguments
+			}
+
+			q.mu.Lock()
+			q.values[addr] = args
+			q.notify(addr, args)
+			q.mu.Unlock()
+		}
+
+	case *Bundle:
+		for _, m := range p.Messages {
+			if err := q.storeValues(m); err != nil {
+				return err
+			}
+		}
+
+		for _, b := range p.Bundles {
+			if err := q.storeValues(b); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addresses returns the sorted addresses with a handler, metadata or value.
+func (q *QueryServer) addresses() []string {
+	seen := make(map[string]bool)
+	for _, addr := range q.dispatcher.Addresses() {
+		seen[addr] = true
+	}
+
+	q.mu.RLock()
+	for addr := range q.metadata {
+		seen[addr] = true
+	}
+	for addr := range q.values {
+		seen[addr] = true
+	}
+	q.mu.RUnlock()
+
+	addrs := make([]string, 0, len(seen))
+	for addr := range seen {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	return addrs
+}
+
+// Root returns the current OSCQuery namespace.
+func (q *QueryServer) Root() *QueryNode {
+	handlers := make(map[string]bool)
+	for _, addr := range q.dispatcher.Addresses() {
+		handlers[addr] = true
+	}
+
+	addrs := q.addresses()
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	root := &QueryNode{FullPath: "/"}
+
+	for _, addr := range addrs {
+		node := root
+		for _, name := range strings.Split(strings.Trim(addr, "/"), "/") {
+			if node.Contents == nil {
+				node.Contents = make(map[string]*QueryNode)
+			}
+
+			child, ok := node.Contents[name]
+			if !ok {
+				child = &QueryNode{FullPath: strings.TrimSuffix(node.FullPath, "/") + "/" + name}
+				node.Contents[name] = child
+			}
+			node = child
+		}
+
+		meta := q.metadata[addr]
+		node.Type = meta.TypeTags
+		node.Range = meta.Range
+		node.Description = meta.Description
+		node.Access = meta.Access
+
+		value, hasValue := q.values[addr]
+		if node.Access == QueryAccessNone {
+			if handlers[addr] {
+				node.Access |= QueryAccessWrite
+			}
+			if hasValue {
+				node.Access |= QueryAccessRead
+			}
+		}
+
+		if hasValue {
+			if node.Type == "" {
+				node.Type = (&Message{Arguments: value}).typeTags()[1:]
+			}
+
+			node.Value = make([]any, len(value))
+			for i, arg := range value {
+				v, err := jsonArgumentValue(arg, true)
+				if err != nil {
+					v = nil
+				}
+				node.Value[i] = v
+			}
+		}
+	}
+
+	return root
+}
+
+// HostInfo returns the answer to a HOST_INFO query.
+func (q *QueryServer) HostInfo() *QueryHostInfo {
+	info := &QueryHostInfo{
+		Name:         q.Name,
+		Extensions:   make(map[string]bool),
+		OSCIP:        q.OSCHost,
+		OSCPort:      q.OSCPort,
+		OSCTransport: "UDP",
+	}
+
+	for _, attr := range queryAttributes {
+		info.Extensions[attr] = true
+	}
+
+	return info
+}
+
+// ServeHTTP answers OSCQuery requests: a path returns the node of the
+// namespace, "?HOST_INFO" the host information and "?ATTRIBUTE" a single
+// attribute of the node. WebSocket clients can LISTEN to the value changes
+// of addresses, see QueryStream. Implements the http.Handler interface.
+func (q *QueryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isWebSocket(r) {
+		// OSCQuery clients don't necessarily send an Origin header
+		websocket.Server{Handler: q.serveWebSocket}.ServeHTTP(w, r)
+		return
+	}
+
+	serveQuery(w, r, q.Root(), q.HostInfo())
+}
+
+// StaticQueryHandler returns an http.Handler answering OSCQuery requests like
+// QueryServer for a fixed namespace, e.g. to fake a remote application in
+// tests. The node of a path is looked up by its name in the contents of the
+// parent nodes.
+func StaticQueryHandler(root *QueryNode, info *QueryHostInfo) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveQuery(w, r, root, info)
+	})
+}
+
+func serveQuery(w http.ResponseWriter, r *http.Request, root *QueryNode, info *QueryHostInfo) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.RawQuery == "HOST_INFO" {
+		writeQueryJSON(w, info)
+		return
+	}
+
+	node := root.Lookup(r.URL.Path)
+	if node == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.URL.RawQuery == "" {
+		writeQueryJSON(w, node)
+		return
+	}
+
+	attr := strings.ToUpper(r.URL.RawQuery)
+	value, ok := node.attribute(attr)
+	switch {
+	case value == nil && ok:
+		w.WriteHeader(http.StatusNoContent)
+	case !ok:
+		http.Error(w, "unsupported attribute "+attr, http.StatusBadRequest)
+	default:
+		writeQueryJSON(w, map[string]any{attr: value})
+	}
+}
+
+// Lookup returns the node with the full path `path`, or nil.
+func (n *QueryNode) Lookup(path string) *QueryNode {
+	node := n
+
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		if name == "" {
+			continue
+		}
+
+		node = node.Contents[name]
+		if node == nil {
+			return nil
+		}
+	}
+
+	return node
+}
+
+// attribute returns the value of the attribute `attr`, which is nil if the
+// node doesn't have the attribute. It returns false for unsupported
+// attributes.
+func (n *QueryNode) attribute(attr string) (any, bool) {
+	switch attr {
+	case "FULL_PATH":
+		return n.FullPath, true
+	case "CONTENTS":
+		if n.Contents == nil {
+			return nil, true
+		}
+		return n.Contents, true
+	case "TYPE":
+		if n.Type == "" {
+			return nil, true
+		}
+		return n.Type, true
+	case "ACCESS":
+		return n.Access, true
+	case "VALUE":
+		if n.Value == nil {
+			return nil, true
+		}
+		return n.Value, true
+	case "RANGE":
+		if n.Range == nil {
+			return nil, true
+		}
+		return n.Range, true
+	case "DESCRIPTION":
+		if n.Description == "" {
+			return nil, true
+		}
+		return n.Description, true
+	}
+
+	return nil, false
+}
+
+func writeQueryJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// validateQueryAddress returns an error if `addr` isn't a valid OSC address.
+func validateQueryAddress(addr string) error {
+	if !strings.HasPrefix(addr, "/") {
+		return ErrorInvalidAddress
+	}
+
+	return validateAddress(addr)
+}
@@ -0,0 +1,139 @@
+package osc
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestQueryServer(t *testing.T) (*QueryServer, *httptest.Server) {
+	d := NewStandardDispatcher()
+	assert.Nil(t, d.AddMsgHandler("/synth/volume", func(msg *Message) {}))
+	assert.Nil(t, d.AddMsgHandler("/synth/note", func(msg *Message) {}))
+
+	q := NewQueryServer(d)
+	q.Name = "test"
+	q.OSCPort = 9000
+	assert.Nil(t, q.Describe("/synth/volume", QueryMetadata{
+		TypeTags:    ",f",
+		Range:       []QueryRange{{Min: 0, Max: 1}},
+		Description: "Master volume",
+		Access:      QueryAccessReadWrite,
+	}))
+
+	ts := httptest.NewServer(q)
+	t.Cleanup(ts.Close)
+
+	return q, ts
+}
+
+// getJSON fetches `path` from the server and returns the status code and the
+// decoded JSON body.
+func getJSON(t *testing.T, ts *httptest.Server, path string) (int, map[string]any) {
+	resp, err := http.Get(ts.URL + path)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+
+	var v map[string]any
+	if resp.StatusCode == http.StatusOK {
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.Nil(t, json.Unmarshal(body, &v), string(body))
+	}
+
+	return resp.StatusCode, v
+}
+
+func TestQueryServerNamespace(t *testing.T) {
+	q, ts := newTestQueryServer(t)
+	assert.Nil(t, q.Dispatch(mustMessage("/synth/*", float32(0.5)), nil))
+
+	status, root := getJSON(t, ts, "/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{
+		"FULL_PATH": "/",
+		"CONTENTS": map[string]any{
+			"synth": map[string]any{
+				"FULL_PATH": "/synth",
+				"CONTENTS": map[string]any{
+					"volume": map[string]any{
+						"FULL_PATH":   "/synth/volume",
+						"TYPE":        "f",
+						"ACCESS":      3.0,
+						"VALUE":       []any{0.5},
+						"RANGE":       []any{map[string]any{"MIN": 0.0, "MAX": 1.0}},
+						"DESCRIPTION": "Master volume",
+					},
+					"note": map[string]any{
+						"FULL_PATH": "/synth/note",
+						"TYPE":      "f",
+						"ACCESS":    3.0,
+						"VALUE":     []any{0.5},
+					},
+				},
+			},
+		},
+	}, root)
+
+	status, node := getJSON(t, ts, "/synth/volume")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "/synth/volume", node["FULL_PATH"])
+
+	status, _ = getJSON(t, ts, "/synth/missing")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestQueryServerAttributes(t *testing.T) {
+	q, ts := newTestQueryServer(t)
+	assert.Nil(t, q.SetValue("/synth/volume", float32(0.25)))
+
+	status, v := getJSON(t, ts, "/synth/volume?VALUE")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"VALUE": []any{0.25}}, v)
+
+	status, v = getJSON(t, ts, "/synth/volume?DESCRIPTION")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"DESCRIPTION": "Master volume"}, v)
+
+	status, v = getJSON(t, ts, "/synth/note?ACCESS")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]any{"ACCESS": 2.0}, v)
+
+	status, _ = getJSON(t, ts, "/synth/note?VALUE")
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status, _ = getJSON(t, ts, "/synth/note?UNKNOWN")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, v = getJSON(t, ts, "/?HOST_INFO")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "test", v["NAME"])
+	assert.Equal(t, 9000.0, v["OSC_PORT"])
+	assert.Equal(t, "UDP", v["OSC_TRANSPORT"])
+	assert.Equal(t, true, v["EXTENSIONS"].(map[string]any)["VALUE"])
+
+	resp, err := http.Post(ts.URL+"/", "application/json", nil)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestQueryServerDescribe(t *testing.T) {
+	q := NewQueryServer(NewStandardDispatcher())
+	assert.Equal(t, ErrorInvalidAddress, q.Describe("synth", QueryMetadata{}))
+	assert.Equal(t, ErrorOscInvalidCharacter, q.Describe("/synth/*", QueryMetadata{}))
+	assert.Equal(t, ErrorOscInvalidCharacter, q.SetValue("/a b", int32(1)))
+
+	assert.Nil(t, q.SetValue("/a/b", int32(1), "x"))
+	assert.Equal(t, ArgumentsType{int32(1), "x"}, q.Value("/a/b"))
+
+	node := q.Root().Lookup("/a/b")
+	assert.Equal(t, &QueryNode{FullPath: "/a/b", Type: "is", Access: QueryAccessRead, Value: []any{int32(1), "x"}}, node)
+	assert.Nil(t, q.Root().Lookup("/a/c"))
+}
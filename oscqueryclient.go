@@ -0,0 +1,356 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OSCQuery client errors
+var (
+	ErrorQueryNotWritable = errors.New("OSCQuery node is not writable")
+	ErrorQueryArguments   = errors.New("arguments don't match the OSCQuery node")
+)
+
+// QueryClient queries the namespace of an OSCQuery server.
+type QueryClient struct {
+	// URL is the base URL of the OSCQuery server, e.g.
+	// "http://localhost:8080".
+	URL string
+
+	// HTTPClient is used for the requests. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+}
+
+// NewQueryClient returns a QueryClient for the OSCQuery server at `url`.
+func NewQueryClient(url string) *QueryClient {
+	return &QueryClient{URL: strings.TrimSuffix(url, "/")}
+}
+
+// Namespace returns the complete namespace of the server.
+func (c *QueryClient) Namespace() (*QueryNode, error) {
+	return c.Node("/")
+}
+
+// Node returns the node with the full path `path` and its descendants.
+func (c *QueryClient) Node(path string) (*QueryNode, error) {
+	var node QueryNode
+	if err := c.get(path, "", &node); err != nil {
+		return nil, err
+	}
+
+	return &node, nil
+}
+
+// HostInfo returns the host information of the server.
+func (c *QueryClient) HostInfo() (*QueryHostInfo, error) {
+	var info QueryHostInfo
+	if err := c.get("/", "HOST_INFO", &info); err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// Value returns the current value of the node with the full path `path`,
+// converted to the types of the node.
+func (c *QueryClient) Value(path string) (ArgumentsType, error) {
+	node, err := c.Node(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Arguments()
+}
+
+// OSCClient returns a Client sending to the OSC address announced by the
+// server's HOST_INFO. If the server doesn't announce an OSC_IP, the host of
+// the server's URL is used.
+func (c *QueryClient) OSCClient() (*Client, error) {
+	info, err := c.HostInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	host := info.OSCIP
+	if host == "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return nil, err
+		}
+		host = u.Hostname()
+	}
+
+	if info.OSCPort == 0 {
+		return nil, fmt.Errorf("osc: %s doesn't announce an OSC port", c.URL)
+	}
+
+	return NewClient(host, info.OSCPort), nil
+}
+
+// get fetches the JSON answer for `path` and `query` into `v`.
+func (c *QueryClient) get(path, query string, v any) error {
+	u := c.URL + "/" + strings.TrimPrefix(path, "/")
+	if query != "" {
+		u += "?" + query
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("osc: GET %s: %s", u, resp.Status)
+	}
+
+	// Keep the precision of int64 and timetag values
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	return dec.Decode(v)
+}
+
+// Children returns the child nodes sorted by name.
+func (n *QueryNode) Children() []*QueryNode {
+	names := make([]string, 0, len(n.Contents))
+	for name := range n.Contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	children := make([]*QueryNode, len(names))
+	for i, name := range names {
+		children[i] = n.Contents[name]
+	}
+
+	return children
+}
+
+// Walk calls `fn` for the node and all its descendants, depth first with the
+// children sorted by name.
+func (n *QueryNode) Walk(fn func(node *QueryNode)) {
+	fn(n)
+
+	for _, child := range n.Children() {
+		child.Walk(fn)
+	}
+}
+
+// Arguments returns the VALUE of the node converted to the types of its TYPE.
+// Values of custom types (see RegisterType) can't be converted and are an
+// error.
+func (n *QueryNode) Arguments() (ArgumentsType, error) {
+	if len(n.Value) == 0 {
+		return nil, nil
+	}
+	if len(n.Value) != len(n.Type) {
+		return nil, fmt.Errorf("%w: %s has %d values for type %q", ErrorQueryArguments, n.FullPath, len(n.Value), n.Type)
+	}
+
+	args := make(ArgumentsType, len(n.Value))
+	for i, v := range n.Value {
+		arg, err := queryArgument(n.Type[i], v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: value %d of %s: %v", ErrorQueryArguments, i, n.FullPath, err)
+		}
+		args[i] = arg
+	}
+
+	return args, nil
+}
+
+// NewMessage returns a message for the node's OSC address. The arguments are
+// converted as described for Message.Append and then to the types of the
+// node, e.g. an int to float32 for type 'f'; arguments of custom types must
+// have the registered Go type. An error wrapping ErrorQueryArguments is
+// returned if the arguments don't match the node's types or are out of its
+// range, ErrorQueryNotWritable if the node is read only.
+func (n *QueryNode) NewMessage(args ...any) (*Message, error) {
+	if n.Access == QueryAccessRead {
+		return nil, ErrorQueryNotWritable
+	}
+	if len(args) != len(n.Type) {
+		return nil, fmt.Errorf("%w: %s takes %d arguments of type %q, got %d", ErrorQueryArguments, n.FullPath, len(n.Type), n.Type, len(args))
+	}
+
+	msg := &Message{Address: n.FullPath}
+
+	for i, arg := range args {
+		v, err := toArgument(arg)
+		if err == nil {
+			v, err = queryArgument(n.Type[i], v)
+		}
+		if err == nil && i < len(n.Range) {
+			err = n.Range[i].check(n.Type[i], v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: argument %d of %s: %v", ErrorQueryArguments, i, n.FullPath, err)
+		}
+
+		msg.Arguments = append(msg.Arguments, v)
+	}
+
+	return msg, nil
+}
+
+// check returns an error if the argument `v` of the type `tag` isn't within
+// the range.
+func (r QueryRange) check(tag byte, v any) error {
+	if len(r.Vals) > 0 {
+		for _, val := range r.Vals {
+			if allowed, err := queryArgument(tag, val); err == nil && reflect.DeepEqual(allowed, v) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%v is not one of %v", v, r.Vals)
+	}
+
+	f, err := coerceFloat64(v)
+	if err != nil {
+		// Only numbers have a minimum and maximum
+		return nil
+	}
+
+	if r.Min != nil {
+		if min, err := queryFloat(r.Min); err == nil && f < min {
+			return fmt.Errorf("%v is less than %v", v, r.Min)
+		}
+	}
+	if r.Max != nil {
+		if max, err := queryFloat(r.Max); err == nil && f > max {
+			return fmt.Errorf("%v is greater than %v", v, r.Max)
+		}
+	}
+
+	return nil
+}
+
+// queryArgument converts the JSON or Go value `v` to an argument of the type
+// `tag`.
+func queryArgument(tag byte, v any) (any, error) {
+	switch tag {
+	case 'i':
+		i, err := queryInt(v)
+		if err != nil {
+			return nil, err
+		}
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return nil, fmt.Errorf("int32 %d out of range", i)
+		}
+		return int32(i), nil
+
+	case 'h':
+		return queryInt(v)
+
+	case 'f':
+		f, err := queryFloat(v)
+		return float32(f), err
+
+	case 'd':
+		return queryFloat(v)
+
+	case 's':
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+
+	case 'b':
+		switch t := v.(type) {
+		case []byte:
+			return t, nil
+		case string:
+			return base64.StdEncoding.DecodeString(t)
+		}
+
+	case 't':
+		if tt, ok := v.(Timetag); ok {
+			return tt, nil
+		}
+		if n, ok := v.(json.Number); ok {
+			tt, err := strconv.ParseUint(n.String(), 10, 64)
+			return Timetag(tt), err
+		}
+
+	case 'T', 'F':
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+
+	case 'N':
+		if v == nil {
+			return nil, nil
+		}
+
+	default:
+		// JSON values can't be converted to custom types
+		if ct := customTypeByTag(tag); ct != nil && reflect.TypeOf(v) == ct.Type {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("type(%T) doesn't match type tag '%c'", v, tag)
+}
+
+// queryInt converts a JSON number or an integral Go number to int64.
+func queryInt(v any) (int64, error) {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return 0, err
+		}
+		v = f
+
+	case bool:
+		return 0, fmt.Errorf("type(%T) is not a number", v)
+	}
+
+	return coerceInt64(v)
+}
+
+// queryFloat converts a JSON number or a Go number to float64.
+func queryFloat(v any) (float64, error) {
+	switch t := v.(type) {
+	case json.Number:
+		return t.Float64()
+
+	case string:
+		// NaN and infinity, see jsonFloat
+		switch t {
+		case "NaN", "+Inf", "-Inf":
+			return strconv.ParseFloat(t, 64)
+		}
+		return 0, fmt.Errorf("type(%T) is not a number", v)
+
+	case bool:
+		return 0, fmt.Errorf("type(%T) is not a number", v)
+	}
+
+	return coerceFloat64(v)
+}
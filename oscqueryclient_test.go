@@ -0,0 +1,178 @@
+package osc
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeNamespace is the namespace of a remote application.
+const fakeNamespace = `{
+	"FULL_PATH": "/",
+	"CONTENTS": {
+		"mixer": {
+			"FULL_PATH": "/mixer",
+			"CONTENTS": {
+				"gain": {
+					"FULL_PATH": "/mixer/gain",
+					"TYPE": "fi",
+					"ACCESS": 3,
+					"VALUE": [0.5, 2],
+					"RANGE": [{"MIN": 0, "MAX": 1}, {"VALS": [1, 2, 3]}]
+				},
+				"name": {
+					"FULL_PATH": "/mixer/name",
+					"TYPE": "s",
+					"ACCESS": 1,
+					"VALUE": ["main"],
+					"DESCRIPTION": "Mixer name"
+				},
+				"big": {
+					"FULL_PATH": "/mixer/big",
+					"TYPE": "htbT",
+					"ACCESS": 3,
+					"VALUE": [9223372036854775807, 18446744073709551615, "AQI=", true]
+				}
+			}
+		}
+	}
+}`
+
+// parseNamespace decodes fakeNamespace, keeping the precision of numbers.
+func parseNamespace(t *testing.T) *QueryNode {
+	var root QueryNode
+	dec := json.NewDecoder(strings.NewReader(fakeNamespace))
+	dec.UseNumber()
+	assert.Nil(t, dec.Decode(&root))
+
+	return &root
+}
+
+func newFakeQueryServer(t *testing.T) *httptest.Server {
+	ts := httptest.NewServer(StaticQueryHandler(parseNamespace(t), &QueryHostInfo{
+		Name:         "fake",
+		OSCPort:      9001,
+		OSCTransport: "UDP",
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestQueryClientNamespace(t *testing.T) {
+	ts := newFakeQueryServer(t)
+	c := NewQueryClient(ts.URL + "/")
+
+	root, err := c.Namespace()
+	assert.Nil(t, err)
+
+	var paths []string
+	root.Walk(func(n *QueryNode) { paths = append(paths, n.FullPath) })
+	assert.Equal(t, []string{"/", "/mixer", "/mixer/big", "/mixer/gain", "/mixer/name"}, paths)
+
+	gain := root.Lookup("/mixer/gain")
+	assert.Equal(t, "fi", gain.Type)
+	assert.Equal(t, QueryAccessReadWrite, gain.Access)
+	assert.Equal(t, 2, len(gain.Range))
+
+	args, err := gain.Arguments()
+	assert.Nil(t, err)
+	assert.Equal(t, ArgumentsType{float32(0.5), int32(2)}, args)
+
+	args, err = c.Value("/mixer/big")
+	assert.Nil(t, err)
+	assert.Equal(t, ArgumentsType{int64(9223372036854775807), Timetag(18446744073709551615), []byte{1, 2}, true}, args)
+
+	node, err := c.Node("/mixer/name")
+	assert.Nil(t, err)
+	assert.Equal(t, "Mixer name", node.Description)
+
+	_, err = c.Node("/missing")
+	assert.NotNil(t, err)
+
+	info, err := c.HostInfo()
+	assert.Nil(t, err)
+	assert.Equal(t, "fake", info.Name)
+
+	client, err := c.OSCClient()
+	assert.Nil(t, err)
+	raddr, err := client.RemoteAddr()
+	assert.Nil(t, err)
+	assert.Equal(t, 9001, raddr.Port)
+	assert.True(t, raddr.IP.IsLoopback())
+}
+
+func TestQueryNodeNewMessage(t *testing.T) {
+	root := parseNamespace(t)
+	gain := root.Lookup("/mixer/gain")
+
+	msg, err := gain.NewMessage(1, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, mustMessage("/mixer/gain", float32(1), int32(3)), msg)
+
+	for _, args := range [][]any{
+		{0.5},
+		{0.5, 2, 3},
+		{1.5, 2},
+		{-0.5, 2},
+		{0.5, 4},
+		{0.5, 2.5},
+		{"x", 2},
+		{true, 2},
+	} {
+		_, err := gain.NewMessage(args...)
+		assert.ErrorIs(t, err, ErrorQueryArguments, "%v", args)
+	}
+
+	_, err = root.Lookup("/mixer/name").NewMessage("x")
+	assert.Equal(t, ErrorQueryNotWritable, err)
+
+	msg, err = root.Lookup("/mixer/big").NewMessage(int64(1), Timetag(2), []byte{3}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, mustMessage("/mixer/big", int64(1), Timetag(2), []byte{3}, true), msg)
+}
+
+func TestQueryClientServer(t *testing.T) {
+	q, ts := newTestQueryServer(t)
+	assert.Nil(t, q.SetValue("/synth/volume", float32(0.75)))
+
+	c := NewQueryClient(ts.URL)
+	node, err := c.Node("/synth/volume")
+	assert.Nil(t, err)
+
+	args, err := node.Arguments()
+	assert.Nil(t, err)
+	assert.Equal(t, ArgumentsType{float32(0.75)}, args)
+
+	msg, err := node.NewMessage(0.25)
+	assert.Nil(t, err)
+	assert.Nil(t, q.Dispatch(msg, nil))
+	assert.Equal(t, ArgumentsType{float32(0.25)}, q.Value("/synth/volume"))
+
+	_, err = node.NewMessage(2)
+	assert.ErrorIs(t, err, ErrorQueryArguments)
+}
+
+func TestQueryNodeCustomType(t *testing.T) {
+	node := &QueryNode{FullPath: "/color", Type: "r", Access: QueryAccessReadWrite, Value: []any{json.Number("1")}}
+
+	// Without a registered type, no argument matches
+	_, err := node.Arguments()
+	assert.ErrorIs(t, err, ErrorQueryArguments)
+	_, err = node.NewMessage(rgba{1, 2, 3, 4})
+	assert.ErrorIs(t, err, ErrorQueryArguments)
+
+	registerRGBA(t)
+
+	_, err = node.Arguments()
+	assert.ErrorIs(t, err, ErrorQueryArguments)
+	_, err = node.NewMessage(1)
+	assert.ErrorIs(t, err, ErrorQueryArguments)
+
+	msg, err := node.NewMessage(rgba{1, 2, 3, 4})
+	assert.Nil(t, err)
+	assert.Equal(t, mustMessage("/color", rgba{1, 2, 3, 4}), msg)
+}
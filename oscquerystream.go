@@ -0,0 +1,197 @@
+package osc
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// OSCQuery WebSocket commands
+const (
+	QueryCommandListen = "LISTEN"
+	QueryCommandIgnore = "IGNORE"
+)
+
+// queryListenerQueue is the number of value changes queued for a WebSocket
+// client. Further changes are dropped until the client catches up.
+const queryListenerQueue = 64
+
+// queryCommand is a command sent by OSCQuery clients over the WebSocket.
+type queryCommand struct {
+	Command string `json:"COMMAND"`
+	Data    string `json:"DATA"`
+}
+
+// queryListener is a WebSocket client of a QueryServer.
+type queryListener struct {
+	paths map[string]bool // guarded by QueryServer.mu
+	queue chan []byte
+}
+
+// isWebSocket returns true if `r` requests a WebSocket upgrade.
+func isWebSocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// serveWebSocket handles the WebSocket connection of an OSCQuery client: it
+// executes the LISTEN and IGNORE commands of the client and sends the value
+// changes of the listened addresses as binary OSC messages.
+func (q *QueryServer) serveWebSocket(ws *websocket.Conn) {
+	l := &queryListener{
+		paths: make(map[string]bool),
+		queue: make(chan []byte, queryListenerQueue),
+	}
+
+	q.mu.Lock()
+	q.listeners[l] = struct{}{}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for data := range l.queue {
+			if err := websocket.Message.Send(ws, data); err != nil {
+				ws.Close()
+				return
+			}
+		}
+	}()
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			break
+		}
+
+		var cmd queryCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			continue
+		}
+
+		q.mu.Lock()
+		switch cmd.Command {
+		case QueryCommandListen:
+			l.paths[cmd.Data] = true
+		case QueryCommandIgnore:
+			delete(l.paths, cmd.Data)
+		}
+		q.mu.Unlock()
+	}
+
+	q.mu.Lock()
+	delete(q.listeners, l)
+	close(l.queue)
+	q.mu.Unlock()
+
+	<-done
+	ws.Close()
+}
+
+// notify sends the new value of `addr` to the WebSocket clients listening to
+// it. q.mu must be held.
+func (q *QueryServer) notify(addr string, args ArgumentsType) {
+	var data []byte
+
+	for l := range q.listeners {
+		if !l.paths[addr] {
+			continue
+		}
+
+		if data == nil {
+			var err error
+			data, err = (&Message{Address: addr, Arguments: args}).MarshalBinary()
+			if err != nil {
+				return
+			}
+		}
+
+		select {
+		case l.queue <- data:
+		default:
+		}
+	}
+}
+
+// QueryStream is a WebSocket connection to an OSCQuery server that receives
+// the value changes of the addresses it listens to.
+type QueryStream struct {
+	ws *websocket.Conn
+	mu sync.Mutex
+}
+
+// Stream opens a WebSocket connection to the server. Use Listen to receive the
+// value changes of an address.
+func (c *QueryClient) Stream() (*QueryStream, error) {
+	url := c.URL
+	switch {
+	case strings.HasPrefix(url, "https://"):
+		url = "wss://" + strings.TrimPrefix(url, "https://")
+	case strings.HasPrefix(url, "http://"):
+		url = "ws://" + strings.TrimPrefix(url, "http://")
+	}
+
+	ws, err := websocket.Dial(url+"/", "", c.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryStream{ws: ws}, nil
+}
+
+// Listen asks the server to send the value changes of the OSC address `path`.
+func (s *QueryStream) Listen(path string) error {
+	return s.send(QueryCommandListen, path)
+}
+
+// Ignore asks the server to stop sending the value changes of `path`.
+func (s *QueryStream) Ignore(path string) error {
+	return s.send(QueryCommandIgnore, path)
+}
+
+func (s *QueryStream) send(command, path string) error {
+	data, err := json.Marshal(queryCommand{Command: command, Data: path})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return websocket.Message.Send(s.ws, string(data))
+}
+
+// Receive returns the next OSC packet sent by the server. Text frames, e.g.
+// namespace change notifications, are skipped.
+func (s *QueryStream) Receive() (Packet, error) {
+	return receiveWebSocket(s.ws)
+}
+
+// Serve dispatches the received packets to `dispatcher` until the connection
+// is closed or the dispatcher returns an error.
+func (s *QueryStream) Serve(dispatcher Dispatcher) error {
+	for {
+		packet, err := s.Receive()
+		if err != nil {
+			return err
+		}
+
+		if err := dispatcher.Dispatch(packet, s.RemoteAddr()); err != nil {
+			return err
+		}
+	}
+}
+
+// RemoteAddr returns the address of the server.
+func (s *QueryStream) RemoteAddr() net.Addr {
+	return s.ws.RemoteAddr()
+}
+
+// Close closes the connection.
+func (s *QueryStream) Close() error {
+	return s.ws.Close()
+}
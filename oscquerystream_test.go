@@ -0,0 +1,98 @@
+package osc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listening returns true if a WebSocket client listens to `addr`.
+func (q *QueryServer) listening(addr string) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for l := range q.listeners {
+		if l.paths[addr] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// waitListening waits until a WebSocket client of `q` listens to `addr`.
+func waitListening(t *testing.T, q *QueryServer, addr string, want bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for q.listening(addr) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("listening(%s) != %v", addr, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueryStream(t *testing.T) {
+	q, ts := newTestQueryServer(t)
+
+	info, err := NewQueryClient(ts.URL).HostInfo()
+	assert.Nil(t, err)
+	assert.True(t, info.Extensions["LISTEN"])
+
+	s, err := NewQueryClient(ts.URL).Stream()
+	assert.Nil(t, err)
+	defer s.Close()
+
+	assert.Nil(t, s.Listen("/synth/volume"))
+	assert.Nil(t, s.Listen("/synth/note"))
+	waitListening(t, q, "/synth/note", true)
+
+	assert.Nil(t, q.Dispatch(mustMessage("/synth/volume", float32(0.5)), nil))
+	p, err := s.Receive()
+	assert.Nil(t, err)
+	assert.Equal(t, mustMessage("/synth/volume", float32(0.5)), p)
+
+	assert.Nil(t, s.Ignore("/synth/volume"))
+	waitListening(t, q, "/synth/volume", false)
+
+	// Only the listened address is sent
+	assert.Nil(t, q.SetValue("/synth/volume", float32(0.1)))
+	assert.Nil(t, q.SetValue("/synth/note", int32(60)))
+	p, err = s.Receive()
+	assert.Nil(t, err)
+	assert.Equal(t, mustMessage("/synth/note", int32(60)), p)
+}
+
+func TestQueryStreamServe(t *testing.T) {
+	q, ts := newTestQueryServer(t)
+
+	s, err := NewQueryClient(ts.URL).Stream()
+	assert.Nil(t, err)
+
+	received := make(chan *Message, 1)
+	d := NewStandardDispatcher()
+	assert.Nil(t, d.AddMsgHandler("/synth/volume", func(msg *Message) {
+		received <- msg
+	}))
+
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(d)
+	}()
+
+	assert.Nil(t, s.Listen("/synth/volume"))
+	waitListening(t, q, "/synth/volume", true)
+	assert.Nil(t, q.SetValue("/synth/volume", float32(0.5)))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, ArgumentsType{float32(0.5)}, msg.Arguments)
+	case <-time.After(5 * time.Second):
+		t.Fatal("value change wasn't dispatched")
+	}
+
+	// The server forgets closed clients
+	assert.Nil(t, s.Close())
+	assert.NotNil(t, <-done)
+	waitListening(t, q, "/synth/volume", false)
+}
@@ -1,8 +1,6 @@
 package osc
 
 import (
-	"bufio"
-	"bytes"
 	"reflect"
 	"testing"
 )
@@ -28,13 +26,12 @@ func TestParsePacket(t *testing.T) {
 		},
 		{"empty", "", nil, false},
 	} {
-		var start int
-		pkt, err := readPacket(bufio.NewReader(bytes.NewBufferString(tt.msg)), &start, len(tt.msg))
+		pkt, err := ParsePacket([]byte(tt.msg))
 		if err != nil && tt.ok {
-			t.Errorf("%s: readPacket() returned unexpected error; %s", tt.desc, err)
+			t.Errorf("%s: ParsePacket() returned unexpected error; %s", tt.desc, err)
 		}
 		if err == nil && !tt.ok {
-			t.Errorf("%s: readPacket() expected error", tt.desc)
+			t.Errorf("%s: ParsePacket() expected error", tt.desc)
 		}
 		if !tt.ok {
 			continue
@@ -51,7 +48,7 @@ func TestParsePacket(t *testing.T) {
 			continue
 		}
 		if got, want := pktBytes, ttpktBytes; !reflect.DeepEqual(got, want) {
-			t.Errorf("%s: readPacket() as bytes = '%s', want = '%s'", tt.desc, got, want)
+			t.Errorf("%s: ParsePacket() as bytes = '%s', want = '%s'", tt.desc, got, want)
 			continue
 		}
 	}
@@ -59,7 +56,7 @@ func TestParsePacket(t *testing.T) {
 
 // makePacket creates a fake Message Packet.
 func makePacket(addr string, args []string) Packet {
-	msg := NewMessage(addr)
+	msg := mustMessage(addr)
 	for _, arg := range args {
 		msg.Append(arg)
 	}
@@ -0,0 +1,395 @@
+package osc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// maxPacketSize is the maximum size of an OSC packet received over UDP.
+const maxPacketSize = 65535
+
+// bufferPool holds receive buffers of maxPacketSize bytes.
+var bufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, maxPacketSize)
+		return &buf
+	},
+}
+
+// messagePool holds messages released by ReleasePacket.
+var messagePool = sync.Pool{
+	New: func() any {
+		return new(Message)
+	},
+}
+
+// ParsePacket decodes the OSC packet in `data`. The returned packet doesn't
+// reference `data`.
+func ParsePacket(data []byte) (Packet, error) {
+	d := decoder{data: data}
+	return d.packet(len(data))
+}
+
+// ParsePacketNoCopy decodes the OSC packet in `data` like ParsePacket, but
+// the strings and blobs of the returned messages alias `data` and the
+// messages are taken from a pool. The packet is only valid as long as `data`
+// isn't modified. Use Clone to keep a message or bundle, and ReleasePacket
+// to return the messages to the pool once the packet isn't used anymore.
+func ParsePacketNoCopy(data []byte) (Packet, error) {
+	d := decoder{data: data, alias: true}
+	return d.packet(len(data))
+}
+
+// ReleasePacket returns the messages of `packet` to the message pool used by
+// ParsePacketNoCopy. Neither the packet nor its messages may be used
+// afterwards.
+func ReleasePacket(packet Packet) {
+	switch p := packet.(type) {
+	case *Message:
+		clear(p.Arguments)
+		p.Address = ""
+		p.Arguments = p.Arguments[:0]
+		messagePool.Put(p)
+
+	case *Bundle:
+		for _, m := range p.Messages {
+			ReleasePacket(m)
+		}
+		for _, b := range p.Bundles {
+			ReleasePacket(b)
+		}
+	}
+}
+
+// Clone returns a deep copy of the OSC message that doesn't share strings or
+// blobs with `msg`.
+func (msg *Message) Clone() *Message {
+	c := &Message{
+		Address:   strings.Clone(msg.Address),
+		Arguments: slices.Clone(msg.Arguments),
+	}
+
+	for i, arg := range c.Arguments {
+		switch t := arg.(type) {
+		case string:
+			c.Arguments[i] = strings.Clone(t)
+		case []byte:
+			c.Arguments[i] = append([]byte{}, t...)
+		case RawArgument:
+			c.Arguments[i] = RawArgument{Tags: strings.Clone(t.Tags), Data: append([]byte{}, t.Data...)}
+		default:
+			c.Arguments[i] = t
+		}
+	}
+
+	return c
+}
+
+// Clone returns a deep copy of the OSC bundle. See Message.Clone.
+func (b *Bundle) Clone() *Bundle {
+	c := &Bundle{
+		Timetag:  b.Timetag,
+		Messages: slices.Clone(b.Messages),
+		Bundles:  slices.Clone(b.Bundles),
+	}
+
+	for i, m := range c.Messages {
+		c.Messages[i] = m.Clone()
+	}
+	for i, nb := range c.Bundles {
+		c.Bundles[i] = nb.Clone()
+	}
+
+	return c
+}
+
+var errShortPacket = errors.New("OSC packet is too short")
+
+// decoder decodes OSC packets directly from a byte slice.
+type decoder struct {
+	data  []byte
+	pos   int
+	alias bool // strings and blobs alias data, messages come from the pool
+}
+
+// packet decodes a message or bundle ending at offset `end`.
+func (d *decoder) packet(end int) (Packet, error) {
+	if d.pos >= end {
+		return nil, ErrorInvalidPacked
+	}
+
+	switch d.data[d.pos] {
+	case '/':
+		return d.message(end)
+
+	case '#':
+		return d.bundle(end)
+	}
+
+	return nil, ErrorInvalidPacked
+}
+
+// bundle decodes a bundle ending at offset `end`.
+func (d *decoder) bundle(end int) (*Bundle, error) {
+	startTag, err := d.paddedString(end)
+	if err != nil {
+		return nil, err
+	}
+
+	if startTag != bundleTagString {
+		return nil, errors.New("Invalid bundle start tag: " + startTag)
+	}
+
+	timeTag, err := d.uint64(end)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{
+		Timetag:  Timetag(timeTag),
+		Messages: []*Message{},
+		Bundles:  []*Bundle{},
+	}
+
+	for end-d.pos >= 4 {
+		length, err := d.uint32(end)
+		if err != nil {
+			return nil, err
+		}
+		if length == 0 {
+			break
+		}
+
+		elemEnd := d.pos + int(int32(length))
+		if int32(length) < 0 || elemEnd > end {
+			return nil, ErrorInvalidPacked
+		}
+
+		p, err := d.packet(elemEnd)
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip element bytes the packet didn't use
+		d.pos = elemEnd
+
+		if err := bundle.Append(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return bundle, nil
+}
+
+// message decodes a message ending at offset `end`.
+func (d *decoder) message(end int) (*Message, error) {
+	addr, err := d.paddedString(end)
+	if err != nil {
+		return nil, err
+	}
+
+	// Released messages keep their argument slice for reuse, messages
+	// without arguments have nil Arguments like those of ParsePacket
+	var msg *Message
+	var args ArgumentsType
+	if d.alias {
+		msg = messagePool.Get().(*Message)
+		args, msg.Arguments = msg.Arguments[:0], nil
+	} else {
+		msg = &Message{}
+	}
+	msg.Address = addr
+
+	typetags, err := d.paddedString(end)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(typetags) == 0 {
+		return msg, nil
+	}
+
+	// If the typetag doesn't start with ',', it's not valid
+	if typetags[0] != ',' {
+		return nil, fmt.Errorf("unsupported type tag string %s", typetags)
+	}
+
+	// Remove ',' from the type tag
+	typetags = typetags[1:]
+
+	for i := 0; i < len(typetags); i++ {
+		var arg any
+
+		switch c := typetags[i]; c {
+		case 'i': // int32
+			v, err := d.uint32(end)
+			if err != nil {
+				return nil, err
+			}
+			arg = int32(v)
+
+		case 'h': // int64
+			v, err := d.uint64(end)
+			if err != nil {
+				return nil, err
+			}
+			arg = int64(v)
+
+		case 'f': // float32
+			v, err := d.uint32(end)
+			if err != nil {
+				return nil, err
+			}
+			arg = math.Float32frombits(v)
+
+		case 'd': // float64/double
+			v, err := d.uint64(end)
+			if err != nil {
+				return nil, err
+			}
+			arg = math.Float64frombits(v)
+
+		case 's': // string
+			s, err := d.paddedString(end)
+			if err != nil {
+				return nil, err
+			}
+			arg = s
+
+		case 'b': // blob
+			b, err := d.blob(end)
+			if err != nil {
+				return nil, err
+			}
+			arg = b
+
+		case 't': // OSC time tag
+			v, err := d.uint64(end)
+			if err != nil {
+				return nil, err
+			}
+			arg = Timetag(v)
+
+		case 'N': // nil
+			arg = nil
+
+		case 'T': // true
+			arg = true
+
+		case 'F': // false
+			arg = false
+
+		default:
+			if ct := customTypeByTag(c); ct != nil {
+				v, n, err := ct.Decode(d.data[d.pos:end])
+				if err != nil {
+					return nil, err
+				}
+				if n < 0 || d.pos+n+padBytesNeeded(n) > end {
+					return nil, errShortPacket
+				}
+				d.pos += n + padBytesNeeded(n)
+				arg = v
+				break
+			}
+
+			// Keep the rest of the message undecoded
+			arg = RawArgument{Tags: typetags[i:], Data: d.bytes(d.pos, end)}
+			d.pos = end
+			i = len(typetags)
+		}
+
+		args = append(args, arg)
+	}
+
+	if len(args) > 0 {
+		msg.Arguments = args
+	}
+
+	return msg, nil
+}
+
+// paddedString decodes a null terminated and padded OSC string.
+func (d *decoder) paddedString(end int) (string, error) {
+	n := 0
+	for d.pos+n < end && d.data[d.pos+n] != 0 {
+		n++
+	}
+	if d.pos+n >= end {
+		return "", errShortPacket
+	}
+
+	size := n + 1 + padBytesNeeded(n+1)
+	if d.pos+size > end {
+		return "", errShortPacket
+	}
+
+	s := d.string(d.pos, d.pos+n)
+	d.pos += size
+
+	return s, nil
+}
+
+// blob decodes an OSC blob.
+func (d *decoder) blob(end int) ([]byte, error) {
+	length, err := d.uint32(end)
+	if err != nil {
+		return nil, err
+	}
+
+	n := int(int32(length))
+	if n < 0 || d.pos+n+padBytesNeeded(n) > end {
+		return nil, fmt.Errorf("readBlob: invalid blob length %d", int32(length))
+	}
+
+	b := d.bytes(d.pos, d.pos+n)
+	d.pos += n + padBytesNeeded(n)
+
+	return b, nil
+}
+
+func (d *decoder) uint32(end int) (uint32, error) {
+	if d.pos+4 > end {
+		return 0, errShortPacket
+	}
+
+	v := binary.BigEndian.Uint32(d.data[d.pos:])
+	d.pos += 4
+
+	return v, nil
+}
+
+func (d *decoder) uint64(end int) (uint64, error) {
+	if d.pos+8 > end {
+		return 0, errShortPacket
+	}
+
+	v := binary.BigEndian.Uint64(d.data[d.pos:])
+	d.pos += 8
+
+	return v, nil
+}
+
+// string returns data[from:to] as a string, aliasing data if requested.
+func (d *decoder) string(from, to int) string {
+	if !d.alias || from == to {
+		return string(d.data[from:to])
+	}
+
+	return unsafe.String(&d.data[from], to-from)
+}
+
+// bytes returns data[from:to], aliasing data if requested.
+func (d *decoder) bytes(from, to int) []byte {
+	if d.alias {
+		return d.data[from:to:to]
+	}
+
+	return append([]byte{}, d.data[from:to]...)
+}
@@ -0,0 +1,172 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseTestBundle returns a nested bundle holding all argument types.
+func parseTestBundle() *Bundle {
+	inner := NewBundle(time.Unix(1700000000, 0))
+	inner.Timetag = Timetag(0x0123456789abcdef)
+	_ = inner.Append(mustMessage("/inner", "x", []byte{}))
+
+	b := NewBundle(time.Unix(1700000000, 0))
+	_ = b.Append(encodingTestMessage())
+	_ = b.Append(mustMessage("/empty"))
+	_ = b.Append(inner)
+
+	return b
+}
+
+func TestParsePacketBytes(t *testing.T) {
+	for _, pkt := range []Packet{
+		mustMessage("/"),
+		mustMessage("/abc", "", []byte{1, 2, 3, 4, 5}),
+		encodingTestMessage(),
+		parseTestBundle(),
+		// Decoded from a released message with arguments
+		mustMessage("/empty"),
+	} {
+		data, err := pkt.MarshalBinary()
+		assert.Nil(t, err)
+
+		got, err := ParsePacket(data)
+		assert.Nil(t, err)
+		assert.Equal(t, pkt, got)
+
+		got, err = ParsePacketNoCopy(data)
+		assert.Nil(t, err)
+		assert.Equal(t, pkt, got)
+		ReleasePacket(got)
+	}
+}
+
+func TestParsePacketErrors(t *testing.T) {
+	data, err := parseTestBundle().MarshalBinary()
+	assert.Nil(t, err)
+
+	// Every truncation of a message fails
+	msg, err := encodingTestMessage().MarshalBinary()
+	assert.Nil(t, err)
+	for i := 0; i < len(msg); i++ {
+		_, err := ParsePacket(msg[:i])
+		assert.NotNil(t, err, "length %d", i)
+	}
+
+	for _, tt := range []struct {
+		desc string
+		data []byte
+	}{
+		{"empty", nil},
+		{"no address", []byte("abc\x00")},
+		{"unterminated address", []byte("/abc")},
+		{"bad type tags", []byte("/a\x00\x00i\x00\x00\x00")},
+		{"bad bundle tag", []byte("#bundlx\x00\x00\x00\x00\x00\x00\x00\x00\x00")},
+		{"element too long", append(append([]byte{}, data[:16]...), 0, 0, 1, 0)},
+		{"negative element length", append(append([]byte{}, data[:16]...), 0xff, 0xff, 0xff, 0xfc)},
+		{"blob too long", []byte("/a\x00\x00,b\x00\x00\x00\x00\x00\x08abcd")},
+	} {
+		_, err := ParsePacket(tt.data)
+		assert.NotNil(t, err, tt.desc)
+	}
+}
+
+func TestParsePacketCustomType(t *testing.T) {
+	registerRGBA(t)
+	msg := mustMessage("/color", rgba{1, 2, 3, 4}, "x")
+	data, err := msg.MarshalBinary()
+	assert.Nil(t, err)
+
+	got, err := ParsePacket(data)
+	assert.Nil(t, err)
+	assert.Equal(t, msg, got)
+
+	UnregisterType('r')
+	got, err = ParsePacket(data)
+	assert.Nil(t, err)
+	assert.Equal(t, ArgumentsType{RawArgument{Tags: "rs", Data: []byte{1, 2, 3, 4, 'x', 0, 0, 0}}}, got.(*Message).Arguments)
+}
+
+func TestParsePacketNoCopy(t *testing.T) {
+	data, err := mustMessage("/a", "abc", []byte{1, 2}).MarshalBinary()
+	assert.Nil(t, err)
+
+	pkt, err := ParsePacketNoCopy(data)
+	assert.Nil(t, err)
+	msg := pkt.(*Message)
+	clone := msg.Clone()
+	copied, err := ParsePacket(data)
+	assert.Nil(t, err)
+
+	// Overwrite the buffer: the aliasing message changes, the others don't
+	for i := range data {
+		if data[i] != 0 {
+			data[i]++
+		}
+	}
+	assert.Equal(t, "0b", msg.Address)
+	assert.Equal(t, "bcd", msg.Arguments[0])
+	assert.Equal(t, []byte{2, 3}, msg.Arguments[1])
+	assert.Equal(t, mustMessage("/a", "abc", []byte{1, 2}), clone)
+	assert.Equal(t, mustMessage("/a", "abc", []byte{1, 2}), copied)
+
+	ReleasePacket(msg)
+	assert.Equal(t, "", msg.Address)
+	assert.Equal(t, 0, len(msg.Arguments))
+}
+
+func TestBundleClone(t *testing.T) {
+	b := parseTestBundle()
+	c := b.Clone()
+	assert.Equal(t, b, c)
+
+	c.Messages[0].Arguments[3].([]byte)[0] = 42
+	assert.Equal(t, byte(4), b.Messages[0].Arguments[3].([]byte)[0])
+}
+
+// recordingDispatcher records the dispatched packets.
+type recordingDispatcher struct {
+	packets []Packet
+}
+
+func (d *recordingDispatcher) Dispatch(packet Packet, _ net.Addr) error {
+	d.packets = append(d.packets, packet)
+	return nil
+}
+
+func TestServerZeroCopy(t *testing.T) {
+	data, err := mustMessage("/a", "abc").MarshalBinary()
+	assert.Nil(t, err)
+
+	d := &recordingDispatcher{}
+	s := &Server{Dispatcher: d, ZeroCopy: true}
+	assert.Nil(t, s.dispatch(data, nil))
+	assert.Nil(t, s.dispatch([]byte("/b\x00\x00,\x00\x00\x00"), nil))
+
+	// Dispatched messages are released after dispatching
+	assert.Equal(t, 2, len(d.packets))
+	assert.Equal(t, 0, len(d.packets[0].(*Message).Arguments))
+}
+
+func BenchmarkParsePacket(b *testing.B) {
+	data, _ := parseTestBundle().MarshalBinary()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = ParsePacket(data)
+	}
+}
+
+func BenchmarkParsePacketNoCopy(b *testing.B) {
+	data, _ := parseTestBundle().MarshalBinary()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		pkt, _ := ParsePacketNoCopy(data)
+		ReleasePacket(pkt)
+	}
+}
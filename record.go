@@ -0,0 +1,462 @@
+package osc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrorInvalidRecording is returned when reading a malformed recording.
+var ErrorInvalidRecording = errors.New("invalid OSC recording")
+
+// RecordFormat is the file format of a recording.
+type RecordFormat int
+
+const (
+	// RecordBinary is a compact binary format: a header followed by one
+	// entry per packet with the time since the previous packet, the source
+	// address and the encoded packet.
+	RecordBinary RecordFormat = iota
+
+	// RecordJSON writes one JSON object per line with the time, the source
+	// address and the packet in the format of ParseJSON.
+	RecordJSON
+)
+
+// recordMagic starts recordings in the binary format, followed by the start
+// time in Unix nanoseconds.
+const recordMagic = "OSCREC\x00\x01"
+
+// Record is a packet received at Time from Addr.
+type Record struct {
+	Time   time.Time
+	Addr   string
+	Packet Packet
+}
+
+// jsonRecord is a line of a recording in the JSON format.
+type jsonRecord struct {
+	Time   time.Time       `json:"time"`
+	Addr   string          `json:"from,omitempty"`
+	Packet json.RawMessage `json:"packet"`
+}
+
+// Recorder writes received packets to a recording. Use it as Dispatcher of a
+// server to record a session.
+type Recorder struct {
+	w      io.Writer
+	format RecordFormat
+
+	mu     sync.Mutex
+	last   time.Time
+	buf    []byte
+	packet []byte
+}
+
+// NewRecorder returns a Recorder writing to `w` in the given format.
+func NewRecorder(w io.Writer, format RecordFormat) (*Recorder, error) {
+	if format != RecordBinary && format != RecordJSON {
+		return nil, fmt.Errorf("osc: unknown record format %d", format)
+	}
+
+	return &Recorder{w: w, format: format}, nil
+}
+
+// Dispatch records the packet received from `raddr` now. Implements the
+// Dispatcher interface.
+func (r *Recorder) Dispatch(packet Packet, raddr net.Addr) error {
+	rec := Record{Time: time.Now(), Packet: packet}
+	if raddr != nil {
+		rec.Addr = raddr.String()
+	}
+
+	return r.Write(rec)
+}
+
+// Write writes the record. Records must be written in chronological order.
+func (r *Recorder) Write(rec Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.format == RecordJSON {
+		packet, err := json.Marshal(rec.Packet)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(jsonRecord{Time: rec.Time, Addr: rec.Addr, Packet: packet})
+		if err != nil {
+			return err
+		}
+
+		_, err = r.w.Write(append(data, '\n'))
+		return err
+	}
+
+	buf := r.buf[:0]
+
+	if r.last.IsZero() {
+		buf = append(buf, recordMagic...)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(rec.Time.UnixNano()))
+		r.last = rec.Time
+	}
+
+	delta := rec.Time.Sub(r.last)
+	if delta < 0 {
+		delta = 0
+	}
+	r.last = r.last.Add(delta)
+
+	packet, err := appendPacket(r.packet[:0], rec.Packet)
+	if err != nil {
+		return err
+	}
+	r.packet = packet
+
+	buf = binary.AppendUvarint(buf, uint64(delta))
+	buf = binary.AppendUvarint(buf, uint64(len(rec.Addr)))
+	buf = append(buf, rec.Addr...)
+	buf = binary.AppendUvarint(buf, uint64(len(packet)))
+	buf = append(buf, packet...)
+
+	r.buf = buf
+	_, err = r.w.Write(buf)
+
+	return err
+}
+
+// RecordReader reads the records of a recording in either format.
+type RecordReader struct {
+	r      *bufio.Reader
+	format RecordFormat
+	last   time.Time
+	buf    []byte
+}
+
+// NewRecordReader returns a reader for the recording `r`. The format is
+// detected from the first bytes.
+func NewRecordReader(r io.Reader) (*RecordReader, error) {
+	rr := &RecordReader{r: bufio.NewReader(r)}
+
+	head, err := rr.r.Peek(len(recordMagic))
+	text := bytes.TrimLeft(head, " \t\r\n")
+
+	switch {
+	case string(head) == recordMagic:
+		rr.format = RecordBinary
+
+		var start [8]byte
+		if _, err := rr.r.Discard(len(recordMagic)); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(rr.r, start[:]); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorInvalidRecording, err)
+		}
+		rr.last = time.Unix(0, int64(binary.BigEndian.Uint64(start[:])))
+
+	case len(text) > 0 && text[0] == '{':
+		rr.format = RecordJSON
+
+	case len(text) == 0 && (err == nil || errors.Is(err, io.EOF)):
+		// An empty recording or leading white space of JSON lines
+		rr.format = RecordJSON
+
+	default:
+		return nil, ErrorInvalidRecording
+	}
+
+	return rr, nil
+}
+
+// Format returns the format of the recording.
+func (rr *RecordReader) Format() RecordFormat {
+	return rr.format
+}
+
+// Read returns the next record, or io.EOF at the end of the recording.
+func (rr *RecordReader) Read() (*Record, error) {
+	if rr.format == RecordJSON {
+		return rr.readJSON()
+	}
+
+	delta, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := rr.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	rec := &Record{Addr: string(addr)}
+
+	data, err := rr.readBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	if rec.Packet, err = ParsePacket(data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidRecording, err)
+	}
+
+	rr.last = rr.last.Add(time.Duration(delta))
+	rec.Time = rr.last
+
+	return rec, nil
+}
+
+// readBytes reads a size prefixed byte string.
+func (rr *RecordReader) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if n > DefaultMaxPacketSize {
+		return nil, ErrorPacketTooLarge
+	}
+
+	if cap(rr.buf) < int(n) {
+		rr.buf = make([]byte, n)
+	}
+	rr.buf = rr.buf[:n]
+
+	if _, err := io.ReadFull(rr.r, rr.buf); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return rr.buf, nil
+}
+
+func (rr *RecordReader) readJSON() (*Record, error) {
+	for {
+		line, err := rr.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var jr jsonRecord
+		if err := json.Unmarshal(line, &jr); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorInvalidRecording, err)
+		}
+
+		packet, err := ParseJSON(jr.Packet)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorInvalidRecording, err)
+		}
+
+		return &Record{Time: jr.Time, Addr: jr.Addr, Packet: packet}, nil
+	}
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF for io.EOF in the middle of a
+// record.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// ReadRecords reads all records of the recording `r`.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	rr, err := NewRecordReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+
+	for {
+		rec, err := rr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, *rec)
+	}
+}
+
+// recordedAddr is the source address of a replayed packet.
+type recordedAddr string
+
+func (a recordedAddr) Network() string {
+	return "recording"
+}
+
+func (a recordedAddr) String() string {
+	return string(a)
+}
+
+// Player replays recorded packets with their original timing.
+type Player struct {
+	Records []Record
+
+	// Speed scales the playback speed, e.g. 2 plays twice as fast. If zero,
+	// the recording is played at its original speed.
+	Speed float64
+
+	// Loop restarts the playback at Start after the last record, unless
+	// nothing was played.
+	Loop bool
+
+	// Start is the position in the recording, relative to the first record,
+	// the playback starts at.
+	Start time.Duration
+
+	// Match holds OSC address patterns. If not empty, only the messages
+	// matching one of the patterns are played.
+	Match []string
+}
+
+// NewPlayer returns a Player for the records of the recording `r`.
+func NewPlayer(r io.Reader) (*Player, error) {
+	records, err := ReadRecords(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Player{Records: records}, nil
+}
+
+// Duration returns the duration of the recording.
+func (p *Player) Duration() time.Duration {
+	if len(p.Records) == 0 {
+		return 0
+	}
+
+	return p.Records[len(p.Records)-1].Time.Sub(p.Records[0].Time)
+}
+
+// Play dispatches the records to `dispatcher` with the recorded source
+// addresses, waiting between records as recorded, until the end of the
+// recording or until `ctx` is done.
+func (p *Player) Play(ctx context.Context, dispatcher Dispatcher) error {
+	if len(p.Records) == 0 {
+		return nil
+	}
+
+	speed := p.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	var patterns []*regexp.Regexp
+	for _, pattern := range p.Match {
+		regex, err := getRegEx(pattern)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, regex)
+	}
+
+	first := p.Records[0].Time
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for {
+		start := time.Now()
+		played := false
+
+		for _, rec := range p.Records {
+			offset := rec.Time.Sub(first) - p.Start
+			if offset < 0 {
+				continue
+			}
+
+			packet := matchPacket(rec.Packet, patterns)
+			if packet == nil {
+				continue
+			}
+
+			if wait := time.Until(start.Add(time.Duration(float64(offset) / speed))); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			} else if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if err := dispatcher.Dispatch(packet, recordedAddr(rec.Addr)); err != nil {
+				return err
+			}
+			played = true
+		}
+
+		if !p.Loop || !played {
+			return nil
+		}
+	}
+}
+
+// PlayTo sends the records to `sender`, see Play.
+func (p *Player) PlayTo(ctx context.Context, sender Sender) error {
+	return p.Play(ctx, senderDispatcher{sender})
+}
+
+// senderDispatcher dispatches packets by sending them.
+type senderDispatcher struct {
+	sender Sender
+}
+
+func (d senderDispatcher) Dispatch(packet Packet, _ net.Addr) error {
+	return d.sender.Send(packet)
+}
+
+// matchPacket returns the packet with only the messages matching one of the
+// patterns, or nil. If there are no patterns, the packet is returned.
+func matchPacket(packet Packet, patterns []*regexp.Regexp) Packet {
+	if len(patterns) == 0 {
+		return packet
+	}
+
+	switch t := packet.(type) {
+	case *Message:
+		for _, regex := range patterns {
+			if regex.MatchString(t.Address) {
+				return t
+			}
+		}
+
+	case *Bundle:
+		b := &Bundle{Timetag: t.Timetag, Messages: []*Message{}, Bundles: []*Bundle{}}
+
+		for _, m := range t.Messages {
+			if matchPacket(m, patterns) != nil {
+				b.Messages = append(b.Messages, m)
+			}
+		}
+
+		for _, nb := range t.Bundles {
+			if m := matchPacket(nb, patterns); m != nil {
+				b.Bundles = append(b.Bundles, m.(*Bundle))
+			}
+		}
+
+		if len(b.Messages) > 0 || len(b.Bundles) > 0 {
+			return b
+		}
+	}
+
+	return nil
+}
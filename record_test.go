@@ -0,0 +1,172 @@
+package osc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRecords() []Record {
+	start := time.Unix(1700000000, 123456789)
+
+	bundle := &Bundle{Timetag: 1, Messages: []*Message{mustMessage("/b", "x")}, Bundles: []*Bundle{}}
+
+	return []Record{
+		{Time: start, Addr: "127.0.0.1:5000", Packet: mustMessage("/a", int32(1))},
+		{Time: start.Add(20 * time.Millisecond), Addr: "", Packet: bundle},
+		{Time: start.Add(40 * time.Millisecond), Addr: "[::1]:6000", Packet: mustMessage("/a", float32(0.5), []byte{1, 2})},
+	}
+}
+
+func TestRecordFormats(t *testing.T) {
+	for _, format := range []RecordFormat{RecordBinary, RecordJSON} {
+		var buf bytes.Buffer
+		r, err := NewRecorder(&buf, format)
+		assert.Nil(t, err)
+
+		for _, rec := range testRecords() {
+			assert.Nil(t, r.Write(rec))
+		}
+
+		rr, err := NewRecordReader(bytes.NewReader(buf.Bytes()))
+		assert.Nil(t, err)
+		assert.Equal(t, format, rr.Format())
+
+		records, err := ReadRecords(bytes.NewReader(buf.Bytes()))
+		assert.Nil(t, err)
+		if assert.Len(t, records, 3) {
+			for i, want := range testRecords() {
+				assert.True(t, want.Time.Equal(records[i].Time), "%v != %v", want.Time, records[i].Time)
+				assert.Equal(t, want.Addr, records[i].Addr)
+				assert.Equal(t, want.Packet, records[i].Packet)
+			}
+		}
+
+		// Truncated recordings
+		if format == RecordBinary {
+			_, err = ReadRecords(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+			assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		}
+	}
+
+	_, err := NewRecorder(io.Discard, RecordFormat(5))
+	assert.NotNil(t, err)
+}
+
+func TestRecordReaderInvalid(t *testing.T) {
+	records, err := ReadRecords(strings.NewReader(""))
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+
+	_, err = ReadRecords(strings.NewReader("garbage"))
+	assert.ErrorIs(t, err, ErrorInvalidRecording)
+
+	_, err = ReadRecords(strings.NewReader(`{"time":"2024-01-01T00:00:00Z","packet":{"address":1}}` + "\n"))
+	assert.ErrorIs(t, err, ErrorInvalidRecording)
+}
+
+func TestRecorderDispatch(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRecorder(&buf, RecordBinary)
+	assert.Nil(t, err)
+
+	before := time.Now()
+	assert.Nil(t, r.Dispatch(mustMessage("/a"), &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 9}))
+	assert.Nil(t, r.Dispatch(mustMessage("/b"), nil))
+
+	records, err := ReadRecords(&buf)
+	assert.Nil(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "10.0.0.1:9", records[0].Addr)
+		assert.Equal(t, "", records[1].Addr)
+		assert.False(t, records[0].Time.Before(before.Truncate(time.Nanosecond)))
+		assert.False(t, records[1].Time.Before(records[0].Time))
+	}
+}
+
+// playedPacket is a packet dispatched by a Player.
+type playedPacket struct {
+	at     time.Duration
+	addr   string
+	packet Packet
+}
+
+type playDispatcher struct {
+	mu     sync.Mutex
+	start  time.Time
+	played []playedPacket
+}
+
+func (d *playDispatcher) Dispatch(packet Packet, raddr net.Addr) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.played = append(d.played, playedPacket{time.Since(d.start), raddr.String(), packet})
+	return nil
+}
+
+func TestPlayer(t *testing.T) {
+	p := &Player{Records: testRecords()}
+	assert.Equal(t, 40*time.Millisecond, p.Duration())
+
+	d := &playDispatcher{start: time.Now()}
+	assert.Nil(t, p.Play(context.Background(), d))
+
+	if assert.Len(t, d.played, 3) {
+		assert.Equal(t, "127.0.0.1:5000", d.played[0].addr)
+		assert.GreaterOrEqual(t, d.played[2].at, 40*time.Millisecond)
+	}
+
+	// Speed, seek and filter
+	p = &Player{Records: testRecords(), Speed: 4, Start: 10 * time.Millisecond, Match: []string{"/b"}}
+	d = &playDispatcher{start: time.Now()}
+	assert.Nil(t, p.Play(context.Background(), d))
+
+	if assert.Len(t, d.played, 1) {
+		assert.Equal(t, testRecords()[1].Packet, d.played[0].packet)
+		assert.GreaterOrEqual(t, d.played[0].at, 2500*time.Microsecond)
+		assert.Less(t, d.played[0].at, 20*time.Millisecond)
+	}
+
+	// Filters apply to the messages of bundles
+	p.Match = []string{"/a"}
+	d = &playDispatcher{start: time.Now()}
+	assert.Nil(t, p.Play(context.Background(), d))
+	assert.Len(t, d.played, 1)
+
+	p.Match = []string{"/a/[b"}
+	assert.NotNil(t, p.Play(context.Background(), d))
+}
+
+func TestPlayerLoop(t *testing.T) {
+	p := &Player{Records: testRecords(), Speed: 10, Loop: true}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	sender := &recordingSender{}
+	assert.ErrorIs(t, p.PlayTo(ctx, sender), context.DeadlineExceeded)
+	assert.Greater(t, len(sender.sent()), 3)
+
+	// Nothing to play
+	p.Start = time.Second
+	assert.Nil(t, p.PlayTo(context.Background(), sender))
+}
+
+func TestNewPlayer(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := NewRecorder(&buf, RecordJSON)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Write(testRecords()[0]))
+
+	p, err := NewPlayer(&buf)
+	assert.Nil(t, err)
+	assert.Len(t, p.Records, 1)
+}
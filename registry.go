@@ -0,0 +1,129 @@
+package osc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// CustomType describes an application specific OSC argument type, e.g. the
+// optional 'c' (char), 'r' (RGBA color) or 'm' (MIDI) types of the OSC 1.0
+// specification or vendor specific type tags.
+type CustomType struct {
+	// Tag is the OSC type tag of the type.
+	Tag byte
+
+	// Type is the Go type of argument values of this type.
+	Type reflect.Type
+
+	// Encode returns the binary representation of the argument `v`. If its
+	// length isn't a multiple of 4, padding bytes are added.
+	Encode func(v any) ([]byte, error)
+
+	// Decode decodes an argument from `data`, which holds the remaining
+	// bytes of the message, and returns the number of bytes used. Padding
+	// bytes after the argument are skipped.
+	Decode func(data []byte) (v any, n int, err error)
+}
+
+// RawArgument holds message arguments that could not be decoded because of an
+// unknown type tag: Tags holds the type tags starting with the unknown one and
+// Data the remaining argument bytes of the message. Messages holding a
+// RawArgument are marshalled back unchanged.
+type RawArgument struct {
+	Tags string
+	Data []byte
+}
+
+// Registry errors
+var (
+	ErrorReservedTypeTag   = errors.New("OSC type tag is reserved for a standard type")
+	ErrorTypeTagRegistered = errors.New("OSC type tag or Go type is registered already")
+)
+
+// customTypes holds all registered custom types.
+var customTypes = struct {
+	sync.RWMutex
+	byTag  map[byte]*CustomType
+	byType map[reflect.Type]*CustomType
+}{
+	byTag:  make(map[byte]*CustomType),
+	byType: make(map[reflect.Type]*CustomType),
+}
+
+// RegisterType registers a custom argument type with the type tag `tag` for
+// Go values of the type of `sample`. Values of that type can then be appended
+// to messages and are encoded with `encode`; arguments with the type tag are
+// decoded with `decode`. See CustomType.
+func RegisterType(tag byte, sample any, encode func(v any) ([]byte, error), decode func(data []byte) (any, int, error)) error {
+	if isStandardTypeTag(tag) {
+		return ErrorReservedTypeTag
+	}
+	if sample == nil || encode == nil || decode == nil {
+		return fmt.Errorf("osc: RegisterType(%c) needs a sample value, encode and decode", tag)
+	}
+
+	ct := &CustomType{
+		Tag:    tag,
+		Type:   reflect.TypeOf(sample),
+		Encode: encode,
+		Decode: decode,
+	}
+
+	customTypes.Lock()
+	defer customTypes.Unlock()
+
+	if _, ok := customTypes.byTag[tag]; ok {
+		return ErrorTypeTagRegistered
+	}
+	if _, ok := customTypes.byType[ct.Type]; ok {
+		return ErrorTypeTagRegistered
+	}
+
+	customTypes.byTag[tag] = ct
+	customTypes.byType[ct.Type] = ct
+
+	return nil
+}
+
+// UnregisterType removes the custom argument type with the type tag `tag`.
+func UnregisterType(tag byte) {
+	customTypes.Lock()
+	defer customTypes.Unlock()
+
+	if ct, ok := customTypes.byTag[tag]; ok {
+		delete(customTypes.byTag, tag)
+		delete(customTypes.byType, ct.Type)
+	}
+}
+
+// customTypeByTag returns the custom type registered for `tag`, or nil.
+func customTypeByTag(tag byte) *CustomType {
+	customTypes.RLock()
+	defer customTypes.RUnlock()
+
+	return customTypes.byTag[tag]
+}
+
+// customTypeOf returns the custom type registered for the Go type of `v`,
+// or nil.
+func customTypeOf(v any) *CustomType {
+	if v == nil {
+		return nil
+	}
+
+	customTypes.RLock()
+	defer customTypes.RUnlock()
+
+	return customTypes.byType[reflect.TypeOf(v)]
+}
+
+// isStandardTypeTag returns true for the type tags handled by the package.
+func isStandardTypeTag(tag byte) bool {
+	switch tag {
+	case 'i', 'f', 's', 'b', 'h', 't', 'd', 'T', 'F', 'N', ',', 0:
+		return true
+	}
+	return false
+}
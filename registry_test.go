@@ -0,0 +1,85 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rgba is the Go type of the OSC 1.0 'r' (RGBA color) type.
+type rgba struct {
+	R, G, B, A uint8
+}
+
+func registerRGBA(t *testing.T) {
+	err := RegisterType('r', rgba{},
+		func(v any) ([]byte, error) {
+			c := v.(rgba)
+			return []byte{c.R, c.G, c.B, c.A}, nil
+		},
+		func(data []byte) (any, int, error) {
+			if len(data) < 4 {
+				return nil, 0, fmt.Errorf("short RGBA value")
+			}
+			return rgba{data[0], data[1], data[2], data[3]}, 4, nil
+		})
+	assert.Nil(t, err)
+	t.Cleanup(func() { UnregisterType('r') })
+}
+
+func TestRegisterType(t *testing.T) {
+	assert.Equal(t, ErrorReservedTypeTag, RegisterType('i', rgba{}, nil, nil))
+
+	registerRGBA(t)
+	assert.Equal(t, ErrorTypeTagRegistered, RegisterType('r', "", func(any) ([]byte, error) { return nil, nil },
+		func([]byte) (any, int, error) { return nil, 0, nil }))
+
+	msg := mustMessage("/color", rgba{1, 2, 3, 4}, int32(5))
+	assert.Equal(t, ",ri", msg.typeTags())
+	assert.Equal(t, "/color ,ri [1 2 3 4] 5", msg.String())
+
+	data, err := msg.MarshalBinary()
+	assert.Nil(t, err)
+
+	d := decoder{data: data}
+	pkt, err := d.packet(len(data))
+	assert.Nil(t, err)
+	assert.Equal(t, msg, pkt)
+	assert.Equal(t, len(data), d.pos)
+}
+
+func TestUnknownTypeTag(t *testing.T) {
+	registerRGBA(t)
+	msg := mustMessage("/color", int32(1), rgba{1, 2, 3, 4}, "x")
+	data, err := msg.MarshalBinary()
+	assert.Nil(t, err)
+	UnregisterType('r')
+
+	bundle := NewBundle(time.Now())
+	assert.Nil(t, bundle.Append(mustMessage("/after")))
+
+	// Put the message before the other bundle element
+	bd, err := bundle.MarshalBinary()
+	assert.Nil(t, err)
+	var packet bytes.Buffer
+	packet.Write(bd[:16])
+	assert.Nil(t, binary.Write(&packet, binary.BigEndian, int32(len(data))))
+	packet.Write(data)
+	packet.Write(bd[16:])
+
+	pkt, err := ParsePacket(packet.Bytes())
+	assert.Nil(t, err)
+
+	b := pkt.(*Bundle)
+	assert.Equal(t, 2, len(b.Messages))
+	assert.Equal(t, ArgumentsType{int32(1), RawArgument{Tags: "rs", Data: []byte{1, 2, 3, 4, 'x', 0, 0, 0}}}, b.Messages[0].Arguments)
+	assert.Equal(t, "/after", b.Messages[1].Address)
+
+	raw, err := b.Messages[0].MarshalBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, data, raw)
+}
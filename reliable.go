@@ -0,0 +1,384 @@
+package osc
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+)
+
+// Reserved OSC addresses used by the reliability layer.
+const (
+	// ReliableSeqAddress is the address of the message that carries the
+	// session id and sequence number of a reliable packet. It is the first
+	// message of the bundle that wraps the packet.
+	ReliableSeqAddress = "/_reliable/seq"
+
+	// ReliableAckAddress is the address of the message that acknowledges a
+	// reliable packet. Its arguments are the session id and sequence number.
+	ReliableAckAddress = "/_reliable/ack"
+)
+
+const (
+	// DefaultRetryInterval is the default delay before the first
+	// retransmission of an unacknowledged packet.
+	DefaultRetryInterval = 50 * time.Millisecond
+
+	// DefaultMaxRetries is the default number of retransmissions before a
+	// delivery fails.
+	DefaultMaxRetries = 5
+
+	// DefaultPeerTimeout is the default time after which the received
+	// sequence numbers of an idle peer session are forgotten.
+	DefaultPeerTimeout = time.Minute
+
+	// reliableDedupWindow is the number of out of order sequence numbers
+	// remembered per peer session.
+	reliableDedupWindow = 1024
+
+	// minPeerSweep is the number of peer sessions at which idle sessions are
+	// first removed.
+	minPeerSweep = 64
+)
+
+// Reliability errors
+var (
+	ErrorDeliveryTimeout = errors.New("OSC packet was not acknowledged")
+	ErrorReliableClosed  = errors.New("reliable OSC layer is closed")
+)
+
+// Delivery tracks the acknowledgement of a packet sent with Reliable.
+type Delivery struct {
+	Seq  uint32
+	Addr net.Addr
+
+	packet  Packet
+	retries int
+	timer   *time.Timer
+	done    chan struct{}
+	err     error
+}
+
+// Done returns a channel that is closed once the packet was acknowledged or
+// the delivery failed.
+func (d *Delivery) Done() <-chan struct{} {
+	return d.done
+}
+
+// Err returns nil if the packet was acknowledged, or the reason the delivery
+// failed. It must only be called after Done is closed.
+func (d *Delivery) Err() error {
+	return d.err
+}
+
+// Wait blocks until the delivery completes and returns its error. Don't call
+// Wait from a message handler of the same ServerAndClient, since
+// acknowledgements are only processed after the handler returns.
+func (d *Delivery) Wait() error {
+	<-d.done
+	return d.err
+}
+
+// Reliable adds acknowledged delivery on top of a ServerAndClient. Outgoing
+// packets are wrapped in a bundle whose first message carries a sequence
+// number, retransmitted with exponential backoff until acknowledged, and
+// de-duplicated by the receiving Reliable before being dispatched. Receivers
+// without a reliability layer still process the wrapped packet normally.
+type Reliable struct {
+	// RetryInterval is the delay before the first retransmission. It is
+	// doubled after every retransmission. If zero, DefaultRetryInterval is
+	// used.
+	RetryInterval time.Duration
+
+	// MaxRetries is the number of retransmissions before a delivery fails
+	// with ErrorDeliveryTimeout. If zero, DefaultMaxRetries is used.
+	MaxRetries int
+
+	// PeerTimeout is the time after which the received sequence numbers of
+	// a peer session, identified by address and session id, are forgotten
+	// if nothing was received from it. It must exceed the time the peer
+	// retransmits a packet, or late retransmissions are dispatched again.
+	// If zero, DefaultPeerTimeout is used.
+	PeerTimeout time.Duration
+
+	sc         *ServerAndClient
+	dispatcher Dispatcher
+	session    int32
+
+	mu      sync.Mutex
+	seq     uint32
+	pending map[uint32]*Delivery
+	peers   map[reliablePeer]*reliableWindow
+	sweepAt int // number of peers at which idle peers are removed
+	closed  bool
+}
+
+// reliablePeer identifies the sending side of a reliable session.
+type reliablePeer struct {
+	addr    string
+	session int32
+}
+
+// reliableWindow remembers the sequence numbers received from a peer.
+type reliableWindow struct {
+	base     uint32 // all sequence numbers up to base have been received
+	seen     map[uint32]struct{}
+	lastSeen time.Time
+}
+
+// NewReliable adds a reliability layer to `sc`. It replaces the dispatcher of
+// the server of `sc` with one that acknowledges and de-duplicates reliable
+// packets before passing them on to the original dispatcher.
+func NewReliable(sc *ServerAndClient) *Reliable {
+	if sc.server.Dispatcher == nil {
+		sc.server.Dispatcher = NewStandardDispatcher()
+	}
+
+	r := &Reliable{
+		sc:         sc,
+		dispatcher: sc.server.Dispatcher,
+		session:    rand.Int32(),
+		pending:    make(map[uint32]*Delivery),
+		peers:      make(map[reliablePeer]*reliableWindow),
+	}
+
+	sc.server.Dispatcher = r
+
+	return r
+}
+
+// SendTo sends an OSC Bundle or an OSC Message reliably to the given address.
+// The returned Delivery reports when the packet was acknowledged.
+func (r *Reliable) SendTo(raddr net.Addr, packet Packet) (*Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, ErrorReliableClosed
+	}
+
+	r.seq++
+	d := &Delivery{
+		Seq:  r.seq,
+		Addr: raddr,
+		done: make(chan struct{}),
+	}
+
+	wrapper := &Bundle{Timetag: NewImmediateTimetag()}
+	_ = wrapper.Append(&Message{
+		Address:   ReliableSeqAddress,
+		Arguments: ArgumentsType{r.session, int32(d.Seq)},
+	})
+	if err := wrapper.Append(packet); err != nil {
+		return nil, err
+	}
+	d.packet = wrapper
+
+	if err := r.sc.SendTo(raddr, wrapper); err != nil {
+		return nil, err
+	}
+
+	r.pending[d.Seq] = d
+	d.timer = time.AfterFunc(r.retryInterval(), func() { r.retransmit(d) })
+
+	return d, nil
+}
+
+// Send sends an OSC Bundle or an OSC Message reliably to the default remote
+// address of the ServerAndClient. Use SendTo to observe the delivery.
+func (r *Reliable) Send(packet Packet) error {
+	_, err := r.SendTo(r.sc.RAddr, packet)
+	return err
+}
+
+// Close stops all retransmissions. Pending deliveries fail with
+// ErrorReliableClosed. The underlying ServerAndClient is not closed.
+func (r *Reliable) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	for seq, d := range r.pending {
+		r.complete(seq, d, ErrorReliableClosed)
+	}
+
+	return nil
+}
+
+// Dispatch acknowledges and de-duplicates reliable packets and passes all
+// other packets to the wrapped dispatcher. Implements the Dispatcher
+// interface.
+func (r *Reliable) Dispatch(packet Packet, raddr net.Addr) error {
+	switch p := packet.(type) {
+	case *Message:
+		if p.Address == ReliableAckAddress {
+			r.handleAck(p)
+			return nil
+		}
+
+	case *Bundle:
+		if len(p.Messages) > 0 && p.Messages[0].Address == ReliableSeqAddress {
+			return r.handleData(p, raddr)
+		}
+	}
+
+	return r.dispatcher.Dispatch(packet, raddr)
+}
+
+// handleAck completes the delivery acknowledged by `msg`.
+func (r *Reliable) handleAck(msg *Message) {
+	session, err1 := msg.Arguments.Int32(0)
+	seq, err2 := msg.Arguments.Int32(1)
+	if err1 != nil || err2 != nil || session != r.session {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d, ok := r.pending[uint32(seq)]; ok {
+		r.complete(uint32(seq), d, nil)
+	}
+}
+
+// handleData acknowledges the reliable bundle `b` and dispatches the wrapped
+// packet if it wasn't received before.
+func (r *Reliable) handleData(b *Bundle, raddr net.Addr) error {
+	marker := b.Messages[0]
+	session, err1 := marker.Arguments.Int32(0)
+	seq, err2 := marker.Arguments.Int32(1)
+	if err1 != nil || err2 != nil {
+		return ErrorInvalidPacked
+	}
+
+	ack := &Message{Address: ReliableAckAddress, Arguments: ArgumentsType{session, seq}}
+	if err := r.sc.SendTo(raddr, ack); err != nil {
+		return err
+	}
+
+	if !r.firstReceipt(reliablePeer{raddr.String(), session}, uint32(seq)) {
+		return nil
+	}
+
+	msgs, bundles := b.Messages[1:], b.Bundles
+	switch {
+	case len(msgs) == 1 && len(bundles) == 0:
+		return r.dispatcher.Dispatch(msgs[0], raddr)
+	case len(msgs) == 0 && len(bundles) == 1:
+		return r.dispatcher.Dispatch(bundles[0], raddr)
+	}
+
+	return r.dispatcher.Dispatch(&Bundle{Timetag: b.Timetag, Messages: msgs, Bundles: bundles}, raddr)
+}
+
+// firstReceipt records `seq` for `peer` and returns false if it was already
+// received.
+func (r *Reliable) firstReceipt(peer reliablePeer, seq uint32) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	w, ok := r.peers[peer]
+	if !ok {
+		r.sweepPeers(now)
+
+		w = &reliableWindow{seen: make(map[uint32]struct{})}
+		r.peers[peer] = w
+	}
+	w.lastSeen = now
+
+	if seq <= w.base {
+		return false
+	}
+	if _, ok := w.seen[seq]; ok {
+		return false
+	}
+
+	w.seen[seq] = struct{}{}
+
+	// Give up on gaps that are too old to be filled by retransmissions
+	for len(w.seen) > reliableDedupWindow {
+		w.base++
+		delete(w.seen, w.base)
+	}
+
+	for {
+		if _, ok := w.seen[w.base+1]; !ok {
+			break
+		}
+		w.base++
+		delete(w.seen, w.base)
+	}
+
+	return true
+}
+
+// sweepPeers removes the peer sessions idle for longer than the peer timeout
+// once the number of peers doubled since the last sweep. The caller must hold
+// r.mu.
+func (r *Reliable) sweepPeers(now time.Time) {
+	if len(r.peers) < max(r.sweepAt, minPeerSweep) {
+		return
+	}
+
+	timeout := r.PeerTimeout
+	if timeout == 0 {
+		timeout = DefaultPeerTimeout
+	}
+
+	for peer, w := range r.peers {
+		if now.Sub(w.lastSeen) > timeout {
+			delete(r.peers, peer)
+		}
+	}
+
+	r.sweepAt = 2 * len(r.peers)
+}
+
+// retransmit resends the packet of `d` or fails the delivery once the
+// retries are exhausted.
+func (r *Reliable) retransmit(d *Delivery) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[d.Seq]; !ok {
+		return
+	}
+
+	if d.retries >= r.maxRetries() {
+		r.complete(d.Seq, d, ErrorDeliveryTimeout)
+		return
+	}
+
+	d.retries++
+	if err := r.sc.SendTo(d.Addr, d.packet); err != nil {
+		r.complete(d.Seq, d, err)
+		return
+	}
+
+	d.timer = time.AfterFunc(r.retryInterval()<<d.retries, func() { r.retransmit(d) })
+}
+
+// complete finishes the delivery `d` with `err`. The caller must hold r.mu.
+func (r *Reliable) complete(seq uint32, d *Delivery, err error) {
+	d.timer.Stop()
+	d.err = err
+	delete(r.pending, seq)
+	close(d.done)
+}
+
+func (r *Reliable) retryInterval() time.Duration {
+	if r.RetryInterval > 0 {
+		return r.RetryInterval
+	}
+	return DefaultRetryInterval
+}
+
+func (r *Reliable) maxRetries() int {
+	if r.MaxRetries > 0 {
+		return r.MaxRetries
+	}
+	return DefaultMaxRetries
+}
@@ -0,0 +1,151 @@
+package osc_test
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/crgimenes/go-osc"
+	"github.com/stretchr/testify/assert"
+)
+
+// dropDispatcher drops the first `drop` packets matching `match` before
+// passing packets on to the wrapped dispatcher.
+type dropDispatcher struct {
+	next  osc.Dispatcher
+	match func(osc.Packet) bool
+	drop  int32
+}
+
+func (d *dropDispatcher) Dispatch(packet osc.Packet, addr net.Addr) error {
+	if d.match(packet) && atomic.AddInt32(&d.drop, -1) >= 0 {
+		return nil
+	}
+	return d.next.Dispatch(packet, addr)
+}
+
+func isAck(p osc.Packet) bool {
+	msg, ok := p.(*osc.Message)
+	return ok && msg.Address == osc.ReliableAckAddress
+}
+
+func isData(p osc.Packet) bool {
+	_, ok := p.(*osc.Bundle)
+	return ok
+}
+
+// newReliablePeer returns a ServerAndClient listening on a free local port.
+func newReliablePeer(t *testing.T, d osc.Dispatcher) *osc.ServerAndClient {
+	sc := osc.NewServerAndClient(d)
+	err := sc.NewConn(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, nil)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		assert.NoError(t, sc.Close())
+	})
+
+	return sc
+}
+
+func TestReliable(t *testing.T) {
+	var received int32
+	var mu sync.Mutex
+	var msgs []*osc.Message
+
+	d2 := osc.NewStandardDispatcher()
+	err := d2.AddMsgHandler("/cue", func(msg *osc.Message) {
+		atomic.AddInt32(&received, 1)
+		mu.Lock()
+		msgs = append(msgs, msg)
+		mu.Unlock()
+	})
+	assert.NoError(t, err)
+
+	app1 := newReliablePeer(t, nil)
+	app2 := newReliablePeer(t, d2)
+	app1.RAddr = app2.Conn().LocalAddr().(*net.UDPAddr)
+
+	r1 := osc.NewReliable(app1)
+	r1.RetryInterval = 10 * time.Millisecond
+	defer r1.Close()
+	r2 := osc.NewReliable(app2)
+	defer r2.Close()
+
+	// Lose the first data packet on the way to app2 and the first ack on the
+	// way back to app1, so the packet is retransmitted and received twice.
+	app2.Server().Dispatcher = &dropDispatcher{next: app2.Server().Dispatcher, match: isData, drop: 1}
+	app1.Server().Dispatcher = &dropDispatcher{next: app1.Server().Dispatcher, match: isAck, drop: 1}
+
+	go app1.ListenAndServe()
+	go app2.ListenAndServe()
+
+	msg, err := osc.NewMessage("/cue", int32(7))
+	assert.NoError(t, err)
+
+	delivery, err := r1.SendTo(app1.RAddr, msg)
+	assert.NoError(t, err)
+
+	select {
+	case <-delivery.Done():
+		assert.NoError(t, delivery.Err())
+	case <-time.After(5 * time.Second):
+		t.Fatal("delivery wasn't confirmed in time")
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&received))
+	mu.Lock()
+	assert.Equal(t, "/cue", msgs[0].Address)
+	assert.Equal(t, int32(7), msgs[0].Arguments[0])
+	mu.Unlock()
+}
+
+func TestReliableTimeout(t *testing.T) {
+	app1 := newReliablePeer(t, nil)
+	app2 := newReliablePeer(t, nil)
+
+	r1 := osc.NewReliable(app1)
+	r1.RetryInterval = time.Millisecond
+	r1.MaxRetries = 2
+	defer r1.Close()
+
+	go app1.ListenAndServe()
+
+	// app2 doesn't serve, so nothing is acknowledged
+	delivery, err := r1.SendTo(app2.Conn().LocalAddr(), &osc.Message{Address: "/cue"})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, delivery.Wait(), osc.ErrorDeliveryTimeout)
+}
+
+func TestReliablePeerTimeout(t *testing.T) {
+	var received int32
+	d := osc.NewStandardDispatcher()
+	assert.NoError(t, d.AddMsgHandler("/cue", func(*osc.Message) {
+		atomic.AddInt32(&received, 1)
+	}))
+
+	r := osc.NewReliable(newReliablePeer(t, d))
+	r.PeerTimeout = time.Nanosecond
+	defer r.Close()
+
+	reliable := func(session int32) *osc.Bundle {
+		return &osc.Bundle{Messages: []*osc.Message{
+			{Address: osc.ReliableSeqAddress, Arguments: osc.ArgumentsType{session, int32(1)}},
+			{Address: "/cue"},
+		}}
+	}
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9}
+
+	// Duplicates are dropped while the session is remembered
+	assert.NoError(t, r.Dispatch(reliable(0), addr))
+	assert.NoError(t, r.Dispatch(reliable(0), addr))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&received))
+
+	// New sessions remove the idle ones
+	for session := int32(1); session <= 100; session++ {
+		assert.NoError(t, r.Dispatch(reliable(session), addr))
+	}
+	assert.NoError(t, r.Dispatch(reliable(0), addr))
+	assert.Equal(t, int32(102), atomic.LoadInt32(&received))
+}
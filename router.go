@@ -0,0 +1,700 @@
+package osc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Router errors
+var (
+	ErrorInvalidRoute = errors.New("invalid OSC route")
+	ErrorRoutingLoop  = errors.New("OSC routing loop detected")
+)
+
+// DefaultLoopWindow is the default time a forwarded packet is remembered for
+// loop detection.
+const DefaultLoopWindow = time.Second
+
+// Route is a forwarding rule of a Router.
+type Route struct {
+	// Match is the OSC address pattern the address of a message must
+	// match, e.g. "/mixer/*/fader". It matches the same addresses as
+	// Message.Match, so '*' may span several address parts. Each wildcard
+	// ('*', '?', "[...]" and "{...}") is a capture for Rewrite. If empty,
+	// all messages match.
+	Match string `json:"match,omitempty"`
+
+	// Source is the IP address or CIDR network, e.g. "192.168.1.0/24", the
+	// message must be received from. If empty, all sources match.
+	Source string `json:"source,omitempty"`
+
+	// Rewrite is the address of the forwarded message. "$1" or "${1}" is
+	// replaced by the text matched by the first wildcard of Match, and so
+	// on. If empty, the address isn't changed.
+	Rewrite string `json:"rewrite,omitempty"`
+
+	// Scale transforms numeric arguments of the forwarded message.
+	Scale []Scale `json:"scale,omitempty"`
+
+	// Transform is called with the forwarded message after the address is
+	// rewritten and the arguments are scaled. It may modify the message or
+	// return another one; if it returns nil, the message is dropped.
+	// Transform can't be set in a configuration file.
+	Transform func(msg *Message) (*Message, error) `json:"-"`
+
+	// To are the targets the message is forwarded to, each either the name
+	// of a target of the Router or the configuration, or a "host:port"
+	// address. Multiple targets duplicate the message.
+	To []string `json:"to,omitempty"`
+
+	// Drop drops matching messages: they are neither forwarded nor matched
+	// against the following routes.
+	Drop bool `json:"drop,omitempty"`
+
+	// Continue matches the message against the following routes, too. By
+	// default, routing stops at the first matching route.
+	Continue bool `json:"continue,omitempty"`
+}
+
+// Scale linearly maps the numeric argument Arg from the range In to the range
+// Out, keeping the type of the argument; integers are rounded.
+type Scale struct {
+	Arg int        `json:"arg"`
+	In  [2]float64 `json:"in"`
+	Out [2]float64 `json:"out"`
+
+	// Clamp limits the result to the range Out.
+	Clamp bool `json:"clamp,omitempty"`
+}
+
+// RouterConfig is the configuration file format of a Router, see
+// Router.LoadConfig.
+type RouterConfig struct {
+	// Targets maps target names to "host:port" addresses.
+	Targets map[string]string `json:"targets,omitempty"`
+
+	// Routes are the forwarding rules, in order.
+	Routes []Route `json:"routes"`
+}
+
+// Router forwards received messages to other OSC servers according to its
+// routes. Use it as the Dispatcher of a Server. The messages of bundles are
+// routed individually and forwarded immediately.
+//
+// Messages received from an address within LoopWindow after the same message
+// was forwarded to that address are dropped, which breaks routing loops with
+// devices echoing their input from the port they listen on. Messages from
+// other ports of the same host are routed.
+type Router struct {
+	// Targets maps target names to senders, e.g. a unix socket Client or a
+	// StreamClient. Names not found here are looked up in the targets of the
+	// configuration and then used as "host:port" address of a Client. Routes
+	// are checked against the targets when set, so set Targets first.
+	Targets map[string]Sender
+
+	// LoopWindow is the time a forwarded packet is remembered for loop
+	// detection. If zero, DefaultLoopWindow is used; if negative, loops
+	// aren't detected.
+	LoopWindow time.Duration
+
+	// OnError is called with the errors of forwarding messages, detected
+	// loops and failed configuration reloads. If nil, errors are ignored.
+	OnError func(err error)
+
+	routes atomic.Pointer[routeSet]
+
+	mu      sync.Mutex
+	clients map[string]*Client
+	sent    map[string]time.Time // loop detection: target address and packet
+}
+
+// routeSet is a compiled configuration.
+type routeSet struct {
+	config RouterConfig
+	routes []*compiledRoute
+}
+
+type compiledRoute struct {
+	Route
+	regex  *regexp.Regexp
+	source *net.IPNet
+}
+
+// NewRouter returns a Router with the named targets and the routes.
+func NewRouter(targets map[string]Sender, routes ...Route) (*Router, error) {
+	r := &Router{Targets: targets}
+	if err := r.SetConfig(RouterConfig{Routes: routes}); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// SetRoutes replaces the routes of the router, keeping the configured targets.
+func (r *Router) SetRoutes(routes ...Route) error {
+	config := r.Config()
+	config.Routes = routes
+
+	return r.SetConfig(config)
+}
+
+// SetConfig replaces the configured targets and routes of the router.
+// Messages being routed finish with the previous configuration.
+func (r *Router) SetConfig(config RouterConfig) error {
+	set := &routeSet{config: config}
+
+	for i, route := range config.Routes {
+		c, err := compileRoute(route)
+		if err != nil {
+			return fmt.Errorf("%w %d: %v", ErrorInvalidRoute, i, err)
+		}
+
+		for _, to := range route.To {
+			if _, ok := config.Targets[to]; ok || r.Targets[to] != nil {
+				continue
+			}
+			if _, _, err := net.SplitHostPort(to); err != nil {
+				return fmt.Errorf("%w %d: unknown target %q", ErrorInvalidRoute, i, to)
+			}
+		}
+
+		set.routes = append(set.routes, c)
+	}
+
+	r.routes.Store(set)
+
+	return nil
+}
+
+// Config returns the current configuration.
+func (r *Router) Config() RouterConfig {
+	if set := r.routes.Load(); set != nil {
+		return set.config
+	}
+
+	return RouterConfig{}
+}
+
+// LoadConfig reads the JSON configuration file `path` and replaces the
+// configured targets and routes. For example:
+//
+//	{
+//		"targets": {"lights": "192.168.1.10:7000"},
+//		"routes": [
+//			{"match": "/mixer/*/fader", "rewrite": "/light/$1/dim",
+//			 "scale": [{"arg": 0, "in": [0, 1], "out": [0, 255]}],
+//			 "to": ["lights"]},
+//			{"match": "/debug/*", "drop": true},
+//			{"source": "10.0.0.0/8", "to": ["10.1.0.2:9000"]}
+//		]
+//	}
+func (r *Router) LoadConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var config RouterConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("osc: %s: %w", path, err)
+	}
+
+	return r.SetConfig(config)
+}
+
+// WatchConfig loads the configuration file `path` and reloads it whenever
+// its modification time or size changes, checking every `interval`, until
+// `ctx` is done. A changed file is reloaded once it stayed unchanged for one
+// interval, so that a file still being written isn't loaded; replace the file
+// atomically with a rename if writing it may stall for longer. The first load
+// error is returned; later errors are passed to OnError and keep the previous
+// configuration.
+func (r *Router) WatchConfig(ctx context.Context, path string, interval time.Duration) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if err := r.LoadConfig(path); err != nil {
+		return err
+	}
+
+	modTime, size := fi.ModTime(), fi.Size()
+	changed := false
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-ticker.C:
+			fi, err := os.Stat(path)
+			if err != nil {
+				r.error(err)
+				continue
+			}
+			if !fi.ModTime().Equal(modTime) || fi.Size() != size {
+				modTime, size = fi.ModTime(), fi.Size()
+				changed = true
+				continue
+			}
+			if !changed {
+				continue
+			}
+
+			changed = false
+			if err := r.LoadConfig(path); err != nil {
+				r.error(err)
+			}
+		}
+	}
+}
+
+// Dispatch routes the messages of the packet received from `raddr`. Errors
+// are reported to OnError, so that a failing target doesn't stop the server.
+// Implements the Dispatcher interface.
+func (r *Router) Dispatch(packet Packet, raddr net.Addr) error {
+	switch p := packet.(type) {
+	case *Message:
+		r.route(p, raddr)
+
+	case *Bundle:
+		for _, m := range p.Messages {
+			r.route(m, raddr)
+		}
+
+		for _, b := range p.Bundles {
+			if err := r.Dispatch(b, raddr); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return ErrorUnsuportedPackage
+	}
+
+	return nil
+}
+
+func (r *Router) route(msg *Message, raddr net.Addr) {
+	set := r.routes.Load()
+	if set == nil {
+		return
+	}
+
+	ip := addrIP(raddr)
+
+	if r.isLoop(raddr, msg) {
+		r.error(fmt.Errorf("%w: %s from %s", ErrorRoutingLoop, msg.Address, raddr))
+		return
+	}
+
+	for _, route := range set.routes {
+		captures := route.match(msg.Address, ip)
+		if captures == nil {
+			continue
+		}
+
+		if route.Drop {
+			return
+		}
+
+		out, err := route.apply(msg, captures)
+		if err != nil {
+			r.error(fmt.Errorf("osc: route %s: %w", route.Match, err))
+		} else if out != nil {
+			for _, to := range route.To {
+				if err := r.forward(set, to, out); err != nil {
+					r.error(fmt.Errorf("osc: forward %s to %s: %w", out.Address, to, err))
+				}
+			}
+		}
+
+		if !route.Continue {
+			return
+		}
+	}
+}
+
+// forward sends the message to the target `to`.
+func (r *Router) forward(set *routeSet, to string, msg *Message) error {
+	sender, err := r.sender(set, to)
+	if err != nil {
+		return err
+	}
+
+	if client, ok := sender.(*Client); ok && client.Network != "unixgram" {
+		if addr, err := client.RemoteAddr(); err == nil {
+			r.remember(addr, msg)
+		}
+	}
+
+	return sender.Send(msg)
+}
+
+// sender returns the sender of the target `to`.
+func (r *Router) sender(set *routeSet, to string) (Sender, error) {
+	if s := r.Targets[to]; s != nil {
+		return s, nil
+	}
+
+	if addr, ok := set.config.Targets[to]; ok {
+		to = addr
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c := r.clients[to]; c != nil {
+		return c, nil
+	}
+
+	host, port, err := net.SplitHostPort(to)
+	if err != nil {
+		return nil, err
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", port)
+	}
+	c := NewClient(host, portNum)
+
+	if r.clients == nil {
+		r.clients = make(map[string]*Client)
+	}
+	r.clients[to] = c
+
+	return c, nil
+}
+
+func (r *Router) loopWindow() time.Duration {
+	if r.LoopWindow == 0 {
+		return DefaultLoopWindow
+	}
+
+	return r.LoopWindow
+}
+
+// loopKey returns the key of the message sent to or received from `addr`.
+func loopKey(addr net.Addr, msg *Message) (string, bool) {
+	if addr == nil {
+		return "", false
+	}
+
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return "", false
+	}
+
+	return addr.String() + "\x00" + string(data), true
+}
+
+// remember records that the message was forwarded to `addr`.
+func (r *Router) remember(addr net.Addr, msg *Message) {
+	window := r.loopWindow()
+	if window < 0 {
+		return
+	}
+
+	key, ok := loopKey(addr, msg)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sent == nil {
+		r.sent = make(map[string]time.Time)
+	}
+
+	// Forget expired packets once in a while
+	if len(r.sent) >= 1024 {
+		for k, t := range r.sent {
+			if now.Sub(t) > window {
+				delete(r.sent, k)
+			}
+		}
+	}
+
+	r.sent[key] = now
+}
+
+// isLoop returns true if the message received from `addr` was forwarded to
+// `addr` within the loop window.
+func (r *Router) isLoop(addr net.Addr, msg *Message) bool {
+	window := r.loopWindow()
+	if window < 0 {
+		return false
+	}
+
+	key, ok := loopKey(addr, msg)
+	if !ok {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.sent[key]
+	if !ok {
+		return false
+	}
+	if time.Since(t) > window {
+		delete(r.sent, key)
+		return false
+	}
+
+	return true
+}
+
+func (r *Router) error(err error) {
+	if r.OnError != nil {
+		r.OnError(err)
+	}
+}
+
+// addrIP returns the IP address of `addr`, or nil.
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case nil:
+		return nil
+	case *net.UDPAddr:
+		return a.IP
+	case *net.TCPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+
+	return net.ParseIP(host)
+}
+
+func compileRoute(route Route) (*compiledRoute, error) {
+	c := &compiledRoute{Route: route}
+
+	if route.Match != "" {
+		regex, err := routePattern(route.Match)
+		if err != nil {
+			return nil, err
+		}
+		c.regex = regex
+	}
+
+	if route.Source != "" {
+		_, ipnet, err := net.ParseCIDR(route.Source)
+		if err != nil {
+			ip := net.ParseIP(route.Source)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid source %q", route.Source)
+			}
+
+			bits := 8 * len(ip)
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			ipnet = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+		c.source = ipnet
+	}
+
+	if route.Rewrite != "" && !strings.HasPrefix(route.Rewrite, "/") {
+		return nil, fmt.Errorf("rewrite %q: %w", route.Rewrite, ErrorInvalidAddress)
+	}
+
+	if !route.Drop && len(route.To) == 0 {
+		return nil, errors.New("no targets")
+	}
+
+	return c, nil
+}
+
+// routePattern compiles an OSC address pattern to a regular expression with
+// a capture group per wildcard. Like CompilePattern, '*' and '?' match '/',
+// too.
+func routePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteByte('^')
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString("(.*)")
+
+		case '?':
+			b.WriteString("(.)")
+
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ']' in %q", pattern)
+			}
+
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("([" + strings.ReplaceAll(class, `\`, `\\`) + "])")
+			i += end
+
+		case '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("missing '}' in %q", pattern)
+			}
+
+			alternatives := strings.Split(pattern[i+1:i+end], ",")
+			for j, alt := range alternatives {
+				alternatives[j] = regexp.QuoteMeta(alt)
+			}
+			b.WriteString("(" + strings.Join(alternatives, "|") + ")")
+			i += end
+
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteByte('$')
+
+	return regexp.Compile(b.String())
+}
+
+// match returns the captures of the wildcards if the route matches the
+// address and source, or nil.
+func (c *compiledRoute) match(addr string, ip net.IP) []string {
+	if c.source != nil && (ip == nil || !c.source.Contains(ip)) {
+		return nil
+	}
+
+	if c.regex == nil {
+		return []string{addr}
+	}
+
+	return c.regex.FindStringSubmatch(addr)
+}
+
+// apply returns the message to forward.
+func (c *compiledRoute) apply(msg *Message, captures []string) (*Message, error) {
+	out := msg.Clone()
+
+	if c.Rewrite != "" {
+		out.Address = expandCaptures(c.Rewrite, captures)
+	}
+
+	for _, s := range c.Scale {
+		if s.Arg < 0 || s.Arg >= len(out.Arguments) {
+			return nil, fmt.Errorf("%s has no argument %d to scale", msg.Address, s.Arg)
+		}
+
+		v, err := s.apply(out.Arguments[s.Arg])
+		if err != nil {
+			return nil, err
+		}
+		out.Arguments[s.Arg] = v
+	}
+
+	if c.Transform != nil {
+		return c.Transform(out)
+	}
+
+	return out, nil
+}
+
+// expandCaptures replaces "$n" and "${n}" in `template` with captures[n].
+func expandCaptures(template string, captures []string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(template); i++ {
+		if template[i] != '$' || i+1 == len(template) {
+			b.WriteByte(template[i])
+			continue
+		}
+
+		j, braced := i+1, template[i+1] == '{'
+		if braced {
+			j++
+		}
+
+		n, k := 0, j
+		for ; k < len(template) && template[k] >= '0' && template[k] <= '9'; k++ {
+			n = 10*n + int(template[k]-'0')
+		}
+
+		if k == j || (braced && (k == len(template) || template[k] != '}')) {
+			b.WriteByte('$')
+			continue
+		}
+
+		if n < len(captures) {
+			b.WriteString(captures[n])
+		}
+
+		if braced {
+			k++
+		}
+		i = k - 1
+	}
+
+	return b.String()
+}
+
+// apply returns the scaled argument `arg`.
+func (s Scale) apply(arg any) (any, error) {
+	f, err := coerceFloat64(arg)
+	if _, ok := arg.(bool); ok || err != nil {
+		return nil, fmt.Errorf("can't scale argument %d of type %T", s.Arg, arg)
+	}
+
+	if s.In[0] != s.In[1] {
+		f = s.Out[0] + (f-s.In[0])*(s.Out[1]-s.Out[0])/(s.In[1]-s.In[0])
+	} else {
+		f = s.Out[0]
+	}
+
+	if s.Clamp {
+		lo, hi := math.Min(s.Out[0], s.Out[1]), math.Max(s.Out[0], s.Out[1])
+		f = math.Max(lo, math.Min(hi, f))
+	}
+
+	switch arg.(type) {
+	case int32:
+		if r := math.Round(f); r >= math.MinInt32 && r <= math.MaxInt32 {
+			return int32(r), nil
+		}
+		return nil, fmt.Errorf("scaled argument %d %v out of range of int32", s.Arg, f)
+	case int64:
+		// float64(math.MaxInt64) is rounded up to 1<<63
+		if r := math.Round(f); r >= math.MinInt64 && r < math.MaxInt64 {
+			return int64(r), nil
+		}
+		return nil, fmt.Errorf("scaled argument %d %v out of range of int64", s.Arg, f)
+	case float32:
+		return float32(f), nil
+	case float64:
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("can't scale argument %d of type %T", s.Arg, arg)
+}
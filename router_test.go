@@ -0,0 +1,255 @@
+package osc
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// takeSent returns and forgets the packets sent to `s`.
+func takeSent(s *recordingSender) []Packet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	packets := s.packets
+	s.packets = nil
+	return packets
+}
+
+var testRouterAddr = &net.UDPAddr{IP: net.IPv4(192, 168, 1, 5), Port: 9000}
+
+func TestRouter(t *testing.T) {
+	lights, mixer := &recordingSender{}, &recordingSender{}
+
+	router, err := NewRouter(map[string]Sender{"lights": lights, "mixer": mixer},
+		Route{Match: "/debug/*", Drop: true},
+		Route{
+			Match:   "/mixer/{ch,bus}/*/fader",
+			Rewrite: "/light/$1${2}/dim",
+			Scale:   []Scale{{Arg: 0, In: [2]float64{0, 1}, Out: [2]float64{0, 255}, Clamp: true}},
+			To:      []string{"lights"},
+		},
+		Route{Match: "/all/*", To: []string{"lights", "mixer"}, Continue: true},
+		Route{Match: "/all/[a-c]", Source: "10.0.0.0/8", To: []string{"mixer"}},
+		Route{Match: "/all/?", Source: "192.168.1.5", To: []string{"mixer"}, Transform: func(msg *Message) (*Message, error) {
+			msg.Address += "/transformed"
+			return msg, nil
+		}},
+	)
+	assert.Nil(t, err)
+
+	for _, tt := range []struct {
+		msg           *Message
+		lights, mixer []Packet
+	}{
+		{mustMessage("/debug/x", "y"), nil, nil},
+		{mustMessage("/mixer/ch/3/fader", float32(0.5)), []Packet{mustMessage("/light/ch3/dim", float32(127.5))}, nil},
+		{mustMessage("/mixer/bus/12/fader", int32(2)), []Packet{mustMessage("/light/bus12/dim", int32(255))}, nil},
+		{mustMessage("/mixer/aux/1/fader", float32(0.5)), nil, nil},
+		{
+			mustMessage("/all/b", "x"),
+			[]Packet{mustMessage("/all/b", "x")},
+			[]Packet{mustMessage("/all/b", "x"), mustMessage("/all/b/transformed", "x")},
+		},
+	} {
+		assert.Nil(t, router.Dispatch(tt.msg, testRouterAddr))
+		assert.Equal(t, tt.lights, takeSent(lights), tt.msg.Address)
+		assert.Equal(t, tt.mixer, takeSent(mixer), tt.msg.Address)
+	}
+
+	// Messages of bundles are routed individually
+	bundle := NewBundle(time.Now())
+	assert.Nil(t, bundle.Append(mustMessage("/all/a")))
+	assert.Nil(t, bundle.Append(mustMessage("/mixer/ch/1/fader", float64(-1))))
+	assert.Nil(t, router.Dispatch(bundle, nil))
+	assert.Equal(t, []Packet{mustMessage("/all/a"), mustMessage("/light/ch1/dim", float64(0))}, takeSent(lights))
+	assert.Equal(t, []Packet{mustMessage("/all/a")}, takeSent(mixer))
+}
+
+func TestRouterErrors(t *testing.T) {
+	for _, route := range []Route{
+		{Match: "/a/[b", To: []string{"127.0.0.1:1"}},
+		{Match: "/a", Source: "nowhere", To: []string{"127.0.0.1:1"}},
+		{Match: "/a", Rewrite: "b", To: []string{"127.0.0.1:1"}},
+		{Match: "/a"},
+		{Match: "/a", To: []string{"unknown"}},
+	} {
+		_, err := NewRouter(nil, route)
+		assert.ErrorIs(t, err, ErrorInvalidRoute, route)
+	}
+
+	var errs []error
+	router, err := NewRouter(map[string]Sender{"x": &recordingSender{}},
+		Route{Match: "/a", Scale: []Scale{{Arg: 1}}, To: []string{"x"}},
+	)
+	assert.Nil(t, err)
+	router.OnError = func(err error) { errs = append(errs, err) }
+
+	assert.Nil(t, router.Dispatch(mustMessage("/a", int32(1)), nil))
+	assert.Nil(t, router.Dispatch(mustMessage("/a", "x", "y"), nil))
+	assert.Len(t, errs, 2)
+}
+
+func TestRouterLoop(t *testing.T) {
+	conn := listenLocal(t, "udp4", "127.0.0.1:0")
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	var errs []error
+	router, err := NewRouter(nil, Route{Match: "/echo", To: []string{joinHostPort("127.0.0.1", port)}})
+	assert.Nil(t, err)
+	router.OnError = func(err error) { errs = append(errs, err) }
+
+	assert.Nil(t, router.Dispatch(mustMessage("/echo", int32(1)), testRouterAddr))
+	assert.Equal(t, []string{"/echo"}, receive(t, conn, 1))
+
+	// The target echoes the message from the port it listens on
+	echo := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}
+	assert.Nil(t, router.Dispatch(mustMessage("/echo", int32(1)), echo))
+	if assert.Len(t, errs, 1) {
+		assert.ErrorIs(t, errs[0], ErrorRoutingLoop)
+	}
+
+	// Other messages and expired packets are forwarded
+	assert.Nil(t, router.Dispatch(mustMessage("/echo", int32(2)), echo))
+	router.LoopWindow = time.Nanosecond
+	assert.Nil(t, router.Dispatch(mustMessage("/echo", int32(2)), echo))
+	assert.Equal(t, []string{"/echo", "/echo"}, receive(t, conn, 2))
+	assert.Len(t, errs, 1)
+}
+
+func TestRouterLoopSameHost(t *testing.T) {
+	conn := listenLocal(t, "udp4", "127.0.0.1:0")
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	var errs []error
+	router, err := NewRouter(nil, Route{Match: "/play", To: []string{joinHostPort("127.0.0.1", port)}})
+	assert.Nil(t, err)
+	router.OnError = func(err error) { errs = append(errs, err) }
+
+	// Other processes on the host of the target aren't loops
+	for _, src := range []int{50000, 50000, 50001} {
+		addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: src}
+		assert.Nil(t, router.Dispatch(mustMessage("/play"), addr))
+	}
+	assert.Equal(t, []string{"/play", "/play", "/play"}, receive(t, conn, 3))
+	assert.Empty(t, errs)
+}
+
+func TestRouterWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.json")
+
+	// Replace the file atomically, so that the watcher never reads a
+	// partially written file
+	writeConfig := func(config string) {
+		assert.Nil(t, os.WriteFile(path+".tmp", []byte(config), 0o644))
+		assert.Nil(t, os.Rename(path+".tmp", path))
+	}
+
+	writeConfig(`{
+		"targets": {"out": "127.0.0.1:9"},
+		"routes": [{"match": "/a/*", "rewrite": "/b/$1", "to": ["out"]}]
+	}`)
+
+	errs := make(chan error, 1)
+	router := &Router{OnError: func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- router.WatchConfig(ctx, path, time.Millisecond)
+	}()
+
+	waitConfig := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for len(router.Config().Routes) != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d routes, want %d", len(router.Config().Routes), n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	waitConfig(1)
+	assert.Equal(t, "/b/$1", router.Config().Routes[0].Rewrite)
+	assert.Equal(t, "127.0.0.1:9", router.Config().Targets["out"])
+
+	writeConfig(`{"routes": [{"match": "/a", "drop": true}, {"match": "/b", "drop": true}]}`)
+	waitConfig(2)
+
+	// Invalid configurations are reported and keep the routes
+	writeConfig(`{"routes": [{"match": "/a"}]}`)
+	assert.ErrorIs(t, <-errs, ErrorInvalidRoute)
+	assert.Len(t, router.Config().Routes, 2)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestExpandCaptures(t *testing.T) {
+	captures := []string{"/a/x/y", "x", "y"}
+
+	for template, want := range map[string]string{
+		"/b/$1/$2":  "/b/x/y",
+		"/b/${1}0":  "/b/x0",
+		"/b/$9":     "/b/",
+		"/b/$":      "/b/$",
+		"/b/$x":     "/b/$x",
+		"/b/${1":    "/b/${1",
+		"/b/$0/end": "/b//a/x/y/end",
+	} {
+		assert.Equal(t, want, expandCaptures(template, captures), template)
+	}
+}
+
+func TestRoutePattern(t *testing.T) {
+	for _, pattern := range []string{"/a/*", "/a/*/c", "/a/?", "/a/{b,c}/*", "/a/[a-c]"} {
+		route, err := routePattern(pattern)
+		assert.Nil(t, err, pattern)
+		match, err := CompilePattern(pattern)
+		assert.Nil(t, err, pattern)
+
+		for _, addr := range []string{"/a", "/a/b", "/a/b/c", "/a/b/x/c", "/a//c", "/b/a"} {
+			assert.Equal(t, match.MatchString(addr), route.MatchString(addr), pattern+" "+addr)
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	s := Scale{In: [2]float64{0, 1}, Out: [2]float64{0, 1e10}}
+
+	for _, tt := range []struct {
+		arg, want any
+	}{
+		{float32(0.5), float32(5e9)},
+		{float64(0.5), float64(5e9)},
+		{int64(1), int64(1e10)},
+		{int32(1), nil},
+		{int64(1e9), nil},
+	} {
+		got, err := s.apply(tt.arg)
+		if tt.want == nil {
+			assert.NotNil(t, err, tt.arg)
+			continue
+		}
+		if assert.Nil(t, err, tt.arg) {
+			assert.Equal(t, tt.want, got, tt.arg)
+		}
+	}
+
+	s = Scale{In: [2]float64{-1, 1}, Out: [2]float64{-1e10, 1e10}}
+	got, err := s.apply(int32(0))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), got)
+	_, err = s.apply(int32(-1))
+	assert.NotNil(t, err)
+}
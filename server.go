@@ -1,9 +1,8 @@
 package osc
 
 import (
-	"bufio"
-	"bytes"
 	"net"
+	"os"
 	"time"
 )
 
@@ -13,7 +12,32 @@ type Server struct {
 	Addr        string
 	Dispatcher  Dispatcher
 	ReadTimeout time.Duration
-	close       func() error
+
+	// Network is the network to listen on: "udp", "udp4", "udp6" or
+	// "unixgram". If empty, "udp" is used. For "unixgram", Addr is the path
+	// of the socket, or its name in the abstract namespace if it starts with
+	// '@' (Linux only).
+	Network string
+
+	// SocketMode sets the permissions of the socket file of a unix domain
+	// socket if not zero. The socket is created with these permissions in a
+	// private directory next to Addr and then linked to Addr, so it is never
+	// accessible with the permissions of the umask. It has no effect on
+	// abstract names starting with '@', which anyone may connect to.
+	SocketMode os.FileMode
+
+	// ZeroCopy makes the server decode packets with ParsePacketNoCopy: the
+	// strings and blobs of dispatched messages alias the receive buffer and
+	// the messages are released once the dispatcher returns. Handlers that
+	// keep a message or its arguments must Clone it.
+	ZeroCopy bool
+
+	// BatchSize is the number of datagrams read per system call if greater
+	// than one. Batch reads use recvmmsg on Linux and are only available for
+	// *net.UDPConn connections; otherwise one datagram is read at a time.
+	BatchSize int
+
+	close func() error
 }
 
 // ListenAndServe retrieves incoming OSC packets and dispatches the retrieved
@@ -25,12 +49,33 @@ func (s *Server) ListenAndServe() error {
 		s.Dispatcher = NewStandardDispatcher()
 	}
 
-	ln, err := net.ListenPacket("udp", s.Addr)
-	if err != nil {
-		return err
+	network := s.Network
+	if network == "" {
+		network = "udp"
 	}
 
-	s.close = ln.Close
+	var ln net.PacketConn
+	var err error
+
+	if network == "unixgram" {
+		ln, err = listenUnix(s.Addr, s.SocketMode, func(addr string) (net.PacketConn, error) {
+			return net.ListenPacket(network, addr)
+		})
+		if err != nil {
+			return err
+		}
+
+		s.close = func() error {
+			defer removeUnixSocket(s.Addr)
+			return ln.Close()
+		}
+	} else {
+		if ln, err = net.ListenPacket(network, s.Addr); err != nil {
+			return err
+		}
+
+		s.close = ln.Close
+	}
 
 	return s.serve(ln)
 }
@@ -38,11 +83,19 @@ func (s *Server) ListenAndServe() error {
 // Serve retrieves incoming OSC packets from the given connection and dispatches
 // retrieved OSC packets. If something goes wrong an error is returned.
 func (s *Server) serve(c net.PacketConn) error {
+	if udp, ok := c.(*net.UDPConn); ok && s.BatchSize > 1 {
+		return s.serveBatch(udp)
+	}
+
 	tempDelay := 25 + time.Millisecond
 
 	for {
-		msg, raddr, err := s.Read(c)
+		buf := bufferPool.Get().(*[]byte)
+
+		n, raddr, err := s.readFrom(c, *buf)
 		if err != nil {
+			bufferPool.Put(buf)
+
 			ne, ok := err.(net.Error)
 
 			if ok && ne.Temporary() {
@@ -52,16 +105,42 @@ func (s *Server) serve(c net.PacketConn) error {
 
 			return err
 		}
-		errChan := make(chan error)
-		go func() {
-			errChan <- s.Dispatcher.Dispatch(msg, raddr)
-		}()
-		if err := <-errChan; err != nil {
+
+		err = s.dispatch((*buf)[:n], raddr)
+		bufferPool.Put(buf)
+		if err != nil {
 			return err
 		}
 	}
 }
 
+// dispatch decodes the packet in `data` and dispatches it.
+func (s *Server) dispatch(data []byte, raddr net.Addr) error {
+	var msg Packet
+	var err error
+
+	if s.ZeroCopy {
+		msg, err = ParsePacketNoCopy(data)
+	} else {
+		msg, err = ParsePacket(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- s.Dispatcher.Dispatch(msg, raddr)
+	}()
+	err = <-errChan
+
+	if s.ZeroCopy {
+		ReleasePacket(msg)
+	}
+
+	return err
+}
+
 // Close forcibly closes a server's connection.
 //
 // This causes a "use of closed network connection" error the next time the
@@ -76,22 +155,27 @@ func (s *Server) Close() error {
 
 // Read retrieves OSC packets.
 func (s *Server) Read(c net.PacketConn) (Packet, net.Addr, error) {
-	if s.ReadTimeout != 0 {
-		err := c.SetReadDeadline(time.Now().Add(s.ReadTimeout))
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-
-	data := make([]byte, 65535)
+	buf := bufferPool.Get().(*[]byte)
+	defer bufferPool.Put(buf)
 
-	n, addr, err := c.ReadFrom(data)
+	n, addr, err := s.readFrom(c, *buf)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var start int
-	p, err := readPacket(bufio.NewReader(bytes.NewBuffer(data)), &start, n)
+	p, err := ParsePacket((*buf)[:n])
 
 	return p, addr, err
 }
+
+// readFrom reads a datagram into `buf`, applying the read timeout.
+func (s *Server) readFrom(c net.PacketConn, buf []byte) (int, net.Addr, error) {
+	if s.ReadTimeout != 0 {
+		err := c.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	return c.ReadFrom(buf)
+}
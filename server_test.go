@@ -54,7 +54,7 @@ func TestListenAndServe(t *testing.T) {
 
 	go func() {
 		client := NewClient("localhost", 8765)
-		msg := NewMessage("/osc/address", int32(111), true, "hello")
+		msg := mustMessage("/osc/address", int32(111), true, "hello")
 		client.Send(msg)
 
 		done.Done()
@@ -90,7 +90,7 @@ func TestReadTimeout(t *testing.T) {
 	go func() {
 		time.Sleep(150 * time.Millisecond)
 		client := NewClient("localhost", 6677)
-		msg := NewMessage("/address/test")
+		msg := mustMessage("/address/test")
 		client.Send(msg)
 
 		wg.Done()
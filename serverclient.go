@@ -2,7 +2,6 @@ package osc
 
 import (
 	"fmt"
-	"math"
 	"net"
 )
 
@@ -52,41 +51,32 @@ func (sc *ServerAndClient) Send(packet Packet) error {
 	return sc.SendTo(sc.RAddr, packet)
 }
 
-// SendMsgTo sends a OSC Message to a given address(all int types converted to int32)
-// Default int is int32, include int values in range of int32
+// SendBatch sends the datagrams with as few system calls as possible. See
+// WriteBatch.
+func (sc *ServerAndClient) SendBatch(datagrams ...Datagram) error {
+	if sc.conn == nil {
+		return fmt.Errorf("ServerAndClient connection is not created")
+	}
+
+	_, err := WriteBatch(sc.conn, datagrams)
+	return err
+}
+
+// SendMsgTo sends a OSC Message to a given address. The arguments are
+// converted as described for Message.Append, so all int types are converted
+// to int32 and must be in range of int32.
 // If you need a int value in range of int64 convert the arg to int64
 func (sc *ServerAndClient) SendMsgTo(addr net.Addr, path string, args ...any) error {
-	var a []any
-
-	for _, arg := range args {
-		switch t := arg.(type) {
-		case int8:
-			a = append(a, int32(t))
-		case uint8:
-			a = append(a, int32(t))
-		case int16:
-			a = append(a, int32(t))
-		case uint16:
-			a = append(a, int32(t))
-		case int:
-			if (t <= math.MaxInt32) && (t >= math.MinInt32) {
-				a = append(a, int32(t))
-			} else {
-				return fmt.Errorf("int32 %d out of range", t)
-			}
-		case bool, int64, int32, float32, float64, string, nil, []byte, Timetag:
-			a = append(a, t)
-		default:
-			return fmt.Errorf("wrong datatype, can't send OSC packet")
-		}
-
+	msg, err := NewMessage(path, args...)
+	if err != nil {
+		return err
 	}
 
-	return sc.SendTo(addr, NewMessage(path, a...))
+	return sc.SendTo(addr, msg)
 }
 
-// SendMsg sends a OSC Message to a given address(all int types converted to int32)
-// Default int is int32, include int values in range of int32
+// SendMsg sends a OSC Message to the default remote address. The arguments
+// are converted as described for Message.Append.
 // If you need a int value in range of int64 convert the arg to int64
 func (sc *ServerAndClient) SendMsg(path string, args ...any) error {
 	return sc.SendMsgTo(sc.RAddr, path, args...)
@@ -0,0 +1,258 @@
+package osc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Framing is the way OSC packets are delimited in a byte stream.
+type Framing int
+
+const (
+	// FramingNone writes packets without delimiters, so a stream holds
+	// a single packet, e.g. a file.
+	FramingNone Framing = iota
+
+	// FramingSizePrefix prefixes each packet with its size as a big endian
+	// int32, as specified for stream transports by OSC 1.0.
+	FramingSizePrefix
+
+	// FramingSLIP delimits packets with double ended SLIP (RFC 1055), as
+	// specified for stream transports by OSC 1.1.
+	FramingSLIP
+)
+
+// DefaultMaxPacketSize is the default maximum size of packets read by a
+// Decoder.
+const DefaultMaxPacketSize = 1 << 20
+
+// SLIP special characters
+const (
+	slipEnd    = 0xc0
+	slipEsc    = 0xdb
+	slipEscEnd = 0xdc
+	slipEscEsc = 0xdd
+)
+
+// Encoder writes OSC packets to a byte stream.
+type Encoder struct {
+	w       io.Writer
+	framing Framing
+	buf     []byte
+}
+
+// NewEncoder returns an Encoder writing packets to `w` delimited by
+// `framing`.
+func NewEncoder(w io.Writer, framing Framing) *Encoder {
+	return &Encoder{w: w, framing: framing}
+}
+
+// Encode writes `packet` to the stream.
+func (e *Encoder) Encode(packet Packet) error {
+	var err error
+
+	switch e.framing {
+	case FramingNone:
+		e.buf, err = appendPacket(e.buf[:0], packet)
+
+	case FramingSizePrefix:
+		e.buf, err = appendPacket(append(e.buf[:0], 0, 0, 0, 0), packet)
+		if err == nil {
+			binary.BigEndian.PutUint32(e.buf, uint32(len(e.buf)-4))
+		}
+
+	case FramingSLIP:
+		e.buf, err = appendPacket(e.buf[:0], packet)
+		if err == nil {
+			e.buf = appendSLIP(e.buf, len(e.buf))
+		}
+
+	default:
+		return fmt.Errorf("osc: unknown framing %d", e.framing)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(e.buf)
+	return err
+}
+
+// appendSLIP appends the SLIP frame of buf[:n] to `buf` and moves it to the
+// beginning of the returned buffer.
+func appendSLIP(buf []byte, n int) []byte {
+	buf = append(buf, slipEnd)
+
+	for _, c := range buf[:n] {
+		switch c {
+		case slipEnd:
+			buf = append(buf, slipEsc, slipEscEnd)
+		case slipEsc:
+			buf = append(buf, slipEsc, slipEscEsc)
+		default:
+			buf = append(buf, c)
+		}
+	}
+
+	buf = append(buf, slipEnd)
+
+	return append(buf[:0], buf[n:]...)
+}
+
+// Decoder reads OSC packets from a byte stream.
+type Decoder struct {
+	// MaxSize is the maximum size of a packet. If zero,
+	// DefaultMaxPacketSize is used.
+	MaxSize int
+
+	r       *bufio.Reader
+	framing Framing
+	buf     []byte
+	done    bool
+}
+
+// NewDecoder returns a Decoder reading packets delimited by `framing` from
+// `r`.
+func NewDecoder(r io.Reader, framing Framing) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), framing: framing}
+}
+
+// Decode reads the next packet from the stream. It returns io.EOF at the end
+// of the stream and io.ErrUnexpectedEOF if the stream ends within a packet.
+func (d *Decoder) Decode() (Packet, error) {
+	var err error
+
+	switch d.framing {
+	case FramingNone:
+		err = d.readAll()
+
+	case FramingSizePrefix:
+		err = d.readSizePrefixed()
+
+	case FramingSLIP:
+		err = d.readSLIP()
+
+	default:
+		return nil, fmt.Errorf("osc: unknown framing %d", d.framing)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(d.buf) == 0 {
+		return nil, ErrorInvalidPacked
+	}
+
+	return ParsePacket(d.buf)
+}
+
+func (d *Decoder) maxSize() int {
+	if d.MaxSize == 0 {
+		return DefaultMaxPacketSize
+	}
+
+	return d.MaxSize
+}
+
+// readAll reads the single packet of the stream into d.buf.
+func (d *Decoder) readAll() error {
+	if d.done {
+		return io.EOF
+	}
+	d.done = true
+
+	var b bytes.Buffer
+	n, err := b.ReadFrom(io.LimitReader(d.r, int64(d.maxSize())+1))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return io.EOF
+	}
+	if n > int64(d.maxSize()) {
+		return ErrorPacketTooLarge
+	}
+
+	d.buf = b.Bytes()
+
+	return nil
+}
+
+// readSizePrefixed reads the next size prefixed packet into d.buf.
+func (d *Decoder) readSizePrefixed() error {
+	var prefix [4]byte
+	if _, err := io.ReadFull(d.r, prefix[:]); err != nil {
+		return err
+	}
+
+	size := int32(binary.BigEndian.Uint32(prefix[:]))
+	if size < 0 {
+		return fmt.Errorf("%w: negative packet size %d", ErrorInvalidFrame, size)
+	}
+	if int(size) > d.maxSize() {
+		return ErrorPacketTooLarge
+	}
+
+	if cap(d.buf) < int(size) {
+		d.buf = make([]byte, size)
+	}
+	d.buf = d.buf[:size]
+
+	_, err := io.ReadFull(d.r, d.buf)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// readSLIP reads the next SLIP frame into d.buf. Empty frames are skipped.
+func (d *Decoder) readSLIP() error {
+	d.buf = d.buf[:0]
+
+	for {
+		c, err := d.r.ReadByte()
+		if err == io.EOF && len(d.buf) > 0 {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+
+		switch c {
+		case slipEnd:
+			if len(d.buf) > 0 {
+				return nil
+			}
+			continue
+
+		case slipEsc:
+			c, err = d.r.ReadByte()
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return err
+			}
+
+			switch c {
+			case slipEscEnd:
+				c = slipEnd
+			case slipEscEsc:
+				c = slipEsc
+			default:
+				return fmt.Errorf("%w: invalid SLIP escape 0x%02x", ErrorInvalidFrame, c)
+			}
+		}
+
+		if len(d.buf) == d.maxSize() {
+			return ErrorPacketTooLarge
+		}
+		d.buf = append(d.buf, c)
+	}
+}
@@ -0,0 +1,121 @@
+package osc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoderDecoder(t *testing.T) {
+	packets := []Packet{
+		encodingTestMessage(),
+		parseTestBundle(),
+		// Holds the SLIP special characters
+		mustMessage("/slip", []byte{slipEnd, slipEsc, slipEscEnd, slipEscEsc}),
+	}
+
+	for _, framing := range []Framing{FramingSizePrefix, FramingSLIP} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf, framing)
+		for _, p := range packets {
+			assert.Nil(t, enc.Encode(p))
+		}
+
+		dec := NewDecoder(&buf, framing)
+		for _, p := range packets {
+			got, err := dec.Decode()
+			assert.Nil(t, err)
+			assert.Equal(t, p, got)
+		}
+
+		_, err := dec.Decode()
+		assert.Equal(t, io.EOF, err)
+	}
+}
+
+func TestEncoderDecoderLargeBlob(t *testing.T) {
+	blob := bytes.Repeat([]byte{1, slipEnd, 2, slipEsc}, 5000)
+	msg := mustMessage("/blob", blob, int32(1))
+
+	for _, framing := range []Framing{FramingSizePrefix, FramingSLIP} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf, framing)
+		assert.Nil(t, enc.Encode(msg))
+		assert.Nil(t, enc.Encode(msg))
+
+		dec := NewDecoder(&buf, framing)
+		for i := 0; i < 2; i++ {
+			got, err := dec.Decode()
+			assert.Nil(t, err)
+			assert.Equal(t, msg, got)
+		}
+	}
+}
+
+func TestEncoderFraming(t *testing.T) {
+	msg := mustMessage("/a", []byte{slipEnd})
+	data, err := msg.MarshalBinary()
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	assert.Nil(t, NewEncoder(&buf, FramingNone).Encode(msg))
+	assert.Equal(t, data, buf.Bytes())
+
+	buf.Reset()
+	assert.Nil(t, NewEncoder(&buf, FramingSizePrefix).Encode(msg))
+	assert.Equal(t, append([]byte{0, 0, 0, byte(len(data))}, data...), buf.Bytes())
+
+	buf.Reset()
+	assert.Nil(t, NewEncoder(&buf, FramingSLIP).Encode(msg))
+	assert.Equal(t, "\xc0/a\x00\x00,b\x00\x00\x00\x00\x00\x01\xdb\xdc\x00\x00\x00\xc0", buf.String())
+}
+
+func TestDecoderNone(t *testing.T) {
+	msg := encodingTestMessage()
+	data, err := msg.MarshalBinary()
+	assert.Nil(t, err)
+
+	dec := NewDecoder(bytes.NewReader(data), FramingNone)
+	got, err := dec.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, msg, got)
+	_, err = dec.Decode()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = NewDecoder(bytes.NewReader(nil), FramingNone).Decode()
+	assert.Equal(t, io.EOF, err)
+
+	dec = NewDecoder(bytes.NewReader(data), FramingNone)
+	dec.MaxSize = len(data) - 1
+	_, err = dec.Decode()
+	assert.Equal(t, ErrorPacketTooLarge, err)
+}
+
+func TestDecoderErrors(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		framing Framing
+		data    string
+		err     error
+	}{
+		{"short size", FramingSizePrefix, "\x00\x00", io.ErrUnexpectedEOF},
+		{"short packet", FramingSizePrefix, "\x00\x00\x00\x08/a\x00\x00", io.ErrUnexpectedEOF},
+		{"negative size", FramingSizePrefix, "\xff\xff\xff\xff", ErrorInvalidFrame},
+		{"too large", FramingSizePrefix, "\x00\x20\x00\x00", ErrorPacketTooLarge},
+		{"unterminated frame", FramingSLIP, "\xc0/a\x00\x00", io.ErrUnexpectedEOF},
+		{"invalid escape", FramingSLIP, "\xc0/a\xdb\x00\xc0", ErrorInvalidFrame},
+	} {
+		_, err := NewDecoder(bytes.NewBufferString(tt.data), tt.framing).Decode()
+		assert.ErrorIs(t, err, tt.err, tt.desc)
+	}
+
+	// Empty SLIP frames are skipped
+	dec := NewDecoder(bytes.NewBufferString("\xc0\xc0\xc0/a\x00\x00,\x00\x00\x00\xc0\xc0"), FramingSLIP)
+	got, err := dec.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, "/a", got.(*Message).Address)
+	_, err = dec.Decode()
+	assert.Equal(t, io.EOF, err)
+}
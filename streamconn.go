@@ -0,0 +1,212 @@
+package osc
+
+import (
+	"net"
+	"os"
+	"sync"
+)
+
+// StreamServer receives OSC packets over stream connections, e.g. unix
+// domain sockets or TCP, delimited by Framing.
+type StreamServer struct {
+	// Network is the network to listen on, e.g. "unix" or "tcp". If empty,
+	// "unix" is used. For "unix", Addr is the path of the socket, or its
+	// name in the abstract namespace if it starts with '@' (Linux only).
+	Network string
+	Addr    string
+
+	// Framing delimits the packets in the streams, e.g. FramingSLIP.
+	Framing Framing
+
+	Dispatcher Dispatcher
+
+	// MaxPacketSize is the maximum size of a received packet. If zero,
+	// DefaultMaxPacketSize is used.
+	MaxPacketSize int
+
+	// SocketMode sets the permissions of the socket file of a unix domain
+	// socket if not zero. The socket is created with these permissions in a
+	// private directory next to Addr and then linked to Addr, so it is never
+	// accessible with the permissions of the umask. It has no effect on
+	// abstract names starting with '@', which anyone may connect to.
+	SocketMode os.FileMode
+
+	mu    sync.Mutex
+	ln    net.Listener
+	conns map[net.Conn]struct{}
+}
+
+// ListenAndServe listens on Addr and dispatches the packets received from
+// the accepted connections.
+func (s *StreamServer) ListenAndServe() error {
+	network := s.Network
+	if network == "" {
+		network = "unix"
+	}
+
+	if network != "unix" || s.SocketMode == 0 || isAbstractSocket(s.Addr) {
+		ln, err := net.Listen(network, s.Addr)
+		if err != nil {
+			return err
+		}
+
+		return s.Serve(ln)
+	}
+
+	ln, err := listenUnix(s.Addr, s.SocketMode, func(addr string) (net.Listener, error) {
+		return net.Listen(network, addr)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(&unixListener{Listener: ln, path: s.Addr})
+}
+
+// Serve accepts connections on `ln` and dispatches the received packets until
+// the listener fails or the server is closed. A connection is closed when a
+// packet can't be decoded or dispatched. The address passed to the
+// dispatcher is the remote address of the connection.
+func (s *StreamServer) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]struct{})
+	}
+	if s.Dispatcher == nil {
+		s.Dispatcher = NewStandardDispatcher()
+	}
+	s.mu.Unlock()
+
+	// Close the connections before waiting for their goroutines
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer s.Close()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(conn)
+		}()
+	}
+}
+
+func (s *StreamServer) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+
+		conn.Close()
+	}()
+
+	dec := NewDecoder(conn, s.Framing)
+	dec.MaxSize = s.MaxPacketSize
+
+	for {
+		packet, err := dec.Decode()
+		if err != nil {
+			return
+		}
+
+		if err := s.Dispatcher.Dispatch(packet, conn.RemoteAddr()); err != nil {
+			return
+		}
+	}
+}
+
+// Close closes the listener and all connections.
+func (s *StreamServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+
+	if s.ln == nil {
+		return nil
+	}
+
+	return s.ln.Close()
+}
+
+// StreamClient sends and receives OSC packets over a stream connection, e.g.
+// a unix domain socket or TCP, delimited by Framing.
+type StreamClient struct {
+	conn net.Conn
+
+	mu  sync.Mutex
+	enc *Encoder
+	dec *Decoder
+}
+
+// DialStream connects to the address `addr` on the named network, e.g. a
+// "unix" socket path, and returns a client delimiting packets by `framing`.
+func DialStream(network, addr string, framing Framing) (*StreamClient, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStreamClient(conn, framing), nil
+}
+
+// NewStreamClient returns a client sending and receiving packets over `conn`
+// delimited by `framing`.
+func NewStreamClient(conn net.Conn, framing Framing) *StreamClient {
+	return &StreamClient{
+		conn: conn,
+		enc:  NewEncoder(conn, framing),
+		dec:  NewDecoder(conn, framing),
+	}
+}
+
+// Send sends an OSC Bundle or an OSC Message. Implements the Sender
+// interface.
+func (c *StreamClient) Send(packet Packet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.enc.Encode(packet)
+}
+
+// Receive returns the next packet received from the connection.
+func (c *StreamClient) Receive() (Packet, error) {
+	return c.dec.Decode()
+}
+
+// Serve dispatches the received packets to `dispatcher` until the connection
+// is closed or the dispatcher returns an error.
+func (c *StreamClient) Serve(dispatcher Dispatcher) error {
+	for {
+		packet, err := c.Receive()
+		if err != nil {
+			return err
+		}
+
+		if err := dispatcher.Dispatch(packet, c.RemoteAddr()); err != nil {
+			return err
+		}
+	}
+}
+
+// RemoteAddr returns the address of the server.
+func (c *StreamClient) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Close closes the connection.
+func (c *StreamClient) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,69 @@
+package osc
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamServer(t *testing.T) {
+	for _, framing := range []Framing{FramingSizePrefix, FramingSLIP} {
+		path := filepath.Join(t.TempDir(), "osc.sock")
+		d, received := messageChannel(t)
+
+		server := &StreamServer{Addr: path, Framing: framing, Dispatcher: d, SocketMode: 0o660}
+		done := make(chan error)
+		go func() {
+			done <- server.ListenAndServe()
+		}()
+
+		var client *StreamClient
+		var err error
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+			if client, err = DialStream("unix", path, framing); err == nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		assert.Nil(t, err)
+
+		fi, err := os.Stat(path)
+		assert.Nil(t, err)
+		assert.Equal(t, os.FileMode(0o660), fi.Mode().Perm())
+
+		// Packets with SLIP special characters
+		msg := mustMessage("/stream", []byte{slipEnd, slipEsc}, "x")
+		for i := 0; i < 3; i++ {
+			assert.Nil(t, client.Send(msg))
+		}
+		for i := 0; i < 3; i++ {
+			expectMessage(t, received, msg)
+		}
+
+		// Closing the server closes the clients and removes the socket file
+		assert.Nil(t, server.Close())
+		assert.NotNil(t, <-done)
+		_, err = client.Receive()
+		assert.NotNil(t, err)
+		_, err = os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestStreamClientServe(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+
+	d, received := messageChannel(t)
+	client := NewStreamClient(clientConn, FramingSLIP)
+	go client.Serve(d)
+	defer client.Close()
+
+	enc := NewEncoder(serverConn, FramingSLIP)
+	assert.Nil(t, enc.Encode(mustMessage("/reply", "ok")))
+	expectMessage(t, received, mustMessage("/reply", "ok"))
+}
@@ -0,0 +1,328 @@
+package osc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrorInvalidText is returned by ParseText for malformed input.
+var ErrorInvalidText = errors.New("invalid OSC text")
+
+// ParseText parses the text representation of an OSC message or bundle, as
+// returned by Message.String and Bundle.String.
+//
+// A message is written as its address pattern, optionally followed by the
+// type tag string and one argument per type tag:
+//
+//	/synth/1/note ,ifsbhdtTFN 60 0.5 "piano" [1 2 3] 9000000000 0.25 1 true false Nil
+//
+// Arguments are written as follows:
+//   - 'i', 'h': decimal integers, or hexadecimal with a 0x prefix
+//   - 'f', 'd': floating point numbers, including NaN and ±Inf
+//   - 's': Go quoted strings; words without spaces may be written unquoted
+//   - 'b': bytes in square brackets, e.g. [1 2 3]
+//   - 't': the timetag as unsigned integer
+//   - 'T', 'F', 'N': true, false and Nil
+//   - custom types (see RegisterType): their encoding as bytes in square
+//     brackets, like blobs
+//   - unknown type tags: the remaining argument data as bytes in square
+//     brackets, see RawArgument
+//
+// A bundle is written as "#bundle", its timetag as unsigned integer and its
+// elements in braces:
+//
+//	#bundle 1 {
+//		/a ,i 1
+//		#bundle 16818286200017484014 {
+//			/b ,s "x"
+//		}
+//	}
+//
+// Elements and arguments are separated by white space; line breaks and
+// indentation are optional.
+func ParseText(s string) (Packet, error) {
+	p := textParser{s: s}
+
+	packet, err := p.packet()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.skipSpace(); p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q after packet", p.s[p.pos:])
+	}
+
+	return packet, nil
+}
+
+// textParser parses the text representation of OSC packets.
+type textParser struct {
+	s   string
+	pos int
+}
+
+func (p *textParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", ErrorInvalidText, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *textParser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek returns the next non space byte, or 0 at the end of the input.
+func (p *textParser) peek() byte {
+	p.skipSpace()
+	if p.pos == len(p.s) {
+		return 0
+	}
+
+	return p.s[p.pos]
+}
+
+// word returns the next word. Words end at white space; words other than
+// address patterns also end at a closing brace or bracket.
+func (p *textParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	address := p.pos < len(p.s) && p.s[p.pos] == '/'
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if unicode.IsSpace(rune(c)) || (!address && (c == '}' || c == ']')) {
+			break
+		}
+		p.pos++
+	}
+
+	return p.s[start:p.pos]
+}
+
+func (p *textParser) packet() (Packet, error) {
+	switch p.peek() {
+	case '/':
+		return p.message()
+
+	case '#':
+		return p.bundle()
+
+	case 0:
+		return nil, p.errorf("missing packet")
+	}
+
+	return nil, p.errorf("packet must start with '/' or '#bundle'")
+}
+
+func (p *textParser) bundle() (*Bundle, error) {
+	if w := p.word(); w != bundleTagString {
+		return nil, p.errorf("invalid bundle tag %q", w)
+	}
+
+	w := p.word()
+	tt, err := strconv.ParseUint(w, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid timetag %q", w)
+	}
+
+	if p.peek() != '{' {
+		return nil, p.errorf("missing '{' after bundle timetag")
+	}
+	p.pos++
+
+	bundle := &Bundle{Timetag: Timetag(tt), Messages: []*Message{}, Bundles: []*Bundle{}}
+
+	for p.peek() != '}' {
+		elem, err := p.packet()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := bundle.Append(elem); err != nil {
+			return nil, err
+		}
+	}
+	p.pos++
+
+	return bundle, nil
+}
+
+func (p *textParser) message() (*Message, error) {
+	msg := &Message{Address: p.word()}
+
+	if p.peek() != ',' {
+		return msg, nil
+	}
+
+	tags := p.word()[1:]
+
+	for i := 0; i < len(tags); i++ {
+		var arg any
+		var err error
+
+		switch tag := tags[i]; tag {
+		case 'i':
+			var v int64
+			v, err = p.int(32)
+			arg = int32(v)
+
+		case 'h':
+			arg, err = p.int(64)
+
+		case 'f':
+			var v float64
+			v, err = p.float(32)
+			arg = float32(v)
+
+		case 'd':
+			arg, err = p.float(64)
+
+		case 's':
+			arg, err = p.string()
+
+		case 'b':
+			arg, err = p.bytes()
+
+		case 't':
+			w := p.word()
+			var v uint64
+			v, err = strconv.ParseUint(w, 0, 64)
+			if err != nil {
+				err = p.errorf("invalid timetag %q", w)
+			}
+			arg = Timetag(v)
+
+		case 'T', 'F', 'N':
+			arg, err = p.constant(tag)
+
+		default:
+			var data []byte
+			data, err = p.bytes()
+			if err != nil {
+				break
+			}
+
+			if ct := customTypeByTag(tag); ct != nil {
+				arg, _, err = ct.Decode(data)
+				break
+			}
+
+			// Keep the rest of the message undecoded
+			arg = RawArgument{Tags: tags[i:], Data: data}
+			i = len(tags)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		msg.Arguments = append(msg.Arguments, arg)
+	}
+
+	return msg, nil
+}
+
+func (p *textParser) int(bitSize int) (int64, error) {
+	w := p.word()
+
+	v, err := strconv.ParseInt(w, 0, bitSize)
+	if err != nil {
+		return 0, p.errorf("invalid int%d %q", bitSize, w)
+	}
+
+	return v, nil
+}
+
+func (p *textParser) float(bitSize int) (float64, error) {
+	w := p.word()
+
+	v, err := strconv.ParseFloat(w, bitSize)
+	if err != nil {
+		return 0, p.errorf("invalid float%d %q", bitSize, w)
+	}
+
+	return v, nil
+}
+
+func (p *textParser) string() (string, error) {
+	switch p.peek() {
+	case 0:
+		return "", p.errorf("missing string")
+
+	case '"':
+
+	default:
+		return p.word(), nil
+	}
+
+	// Find the closing quote
+	end := p.pos + 1
+	for ; end < len(p.s) && p.s[end] != '"'; end++ {
+		if p.s[end] == '\\' {
+			end++
+		}
+	}
+	if end >= len(p.s) {
+		return "", p.errorf("unterminated string")
+	}
+
+	s, err := strconv.Unquote(p.s[p.pos : end+1])
+	if err != nil {
+		return "", p.errorf("invalid string %s", p.s[p.pos:end+1])
+	}
+	p.pos = end + 1
+
+	return s, nil
+}
+
+func (p *textParser) bytes() ([]byte, error) {
+	if p.peek() != '[' {
+		return nil, p.errorf("missing '[' before bytes")
+	}
+	p.pos++
+
+	data := []byte{}
+
+	for {
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+
+		switch p.peek() {
+		case ']':
+			p.pos++
+			return data, nil
+
+		case 0:
+			return nil, p.errorf("missing ']' after bytes")
+		}
+
+		w := p.word()
+		v, err := strconv.ParseUint(strings.TrimSuffix(w, ","), 0, 8)
+		if err != nil {
+			return nil, p.errorf("invalid byte %q", w)
+		}
+		data = append(data, byte(v))
+	}
+}
+
+func (p *textParser) constant(tag byte) (any, error) {
+	w := p.word()
+
+	switch {
+	case tag == 'T' && strings.EqualFold(w, "true"):
+		return true, nil
+
+	case tag == 'F' && strings.EqualFold(w, "false"):
+		return false, nil
+
+	case tag == 'N' && strings.EqualFold(w, "nil"):
+		return nil, nil
+	}
+
+	return nil, p.errorf("invalid value %q for type tag %c", w, tag)
+}
@@ -0,0 +1,95 @@
+package osc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleString(t *testing.T) {
+	assert.Equal(t, "", (*Bundle)(nil).String())
+	assert.Equal(t, "#bundle 1 {}", (&Bundle{Timetag: 1}).String())
+
+	b := &Bundle{Timetag: 1}
+	_ = b.Append(mustMessage("/a", int32(1)))
+	nb := &Bundle{Timetag: 16818286200017484014}
+	_ = nb.Append(mustMessage("/b", "x"))
+	_ = b.Append(nb)
+
+	assert.Equal(t, "#bundle 1 {\n\t/a ,i 1\n\t#bundle 16818286200017484014 {\n\t\t/b ,s \"x\"\n\t}\n}", b.String())
+}
+
+func TestParseTextRoundTrip(t *testing.T) {
+	registerRGBA(t)
+
+	for _, pkt := range []Packet{
+		mustMessage("/"),
+		mustMessage("/foo/bar", nil),
+		mustMessage("/a/{b,c}/[0-9]", "with \"quotes\"\n and spaces", "", []byte{}),
+		mustMessage("/floats", float32(0.1), float32(-1e-30), 0.1, math.Inf(1), math.Inf(-1), float32(math.MaxFloat32)),
+		mustMessage("/ints", int32(math.MinInt32), int64(math.MaxInt64), Timetag(math.MaxUint64)),
+		mustMessage("/custom", rgba{1, 2, 3, 4}, true),
+		mustMessage("/raw", int32(1), RawArgument{Tags: "xs", Data: []byte{1, 2, 3, 4, 'a', 0, 0, 0}}),
+		encodingTestMessage(),
+		parseTestBundle(),
+		NewBundle(timetagToTime(1)),
+	} {
+		var s string
+		switch p := pkt.(type) {
+		case *Message:
+			s = p.String()
+		case *Bundle:
+			s = p.String()
+		}
+
+		got, err := ParseText(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, pkt, got, s)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		pkt  Packet
+	}{
+		{"/a", mustMessage("/a")},
+		{"  /a ,  ", mustMessage("/a")},
+		{"/a ,sib word 0x10 [1, 2, 0xff]", mustMessage("/a", "word", int32(16), []byte{1, 2, 255})},
+		{"/a ,TFN TRUE False nil", mustMessage("/a", true, false, nil)},
+		{"#bundle 1 {/a ,i 1 /b ,s x}", &Bundle{Timetag: 1, Messages: []*Message{
+			mustMessage("/a", int32(1)), mustMessage("/b", "x")}, Bundles: []*Bundle{}}},
+	} {
+		got, err := ParseText(tt.text)
+		assert.Nil(t, err, tt.text)
+		assert.Equal(t, tt.pkt, got, tt.text)
+	}
+}
+
+func TestParseTextErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"a",
+		"/a ,i",
+		"/a ,i x",
+		"/a ,i 4294967296",
+		"/a ,f x",
+		"/a ,s",
+		"/a ,s \"x",
+		"/a ,b 1",
+		"/a ,b [1 256]",
+		"/a ,b [1",
+		"/a ,T false",
+		"/a ,t -1",
+		"/a /b",
+		"#bundle",
+		"#bundle x {}",
+		"#bundle 1",
+		"#bundle 1 { /a",
+		"#bundlex 1 {}",
+	} {
+		_, err := ParseText(text)
+		assert.ErrorIs(t, err, ErrorInvalidText, text)
+	}
+}
@@ -0,0 +1,267 @@
+package osc
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle limits the rate of outgoing OSC messages. Each OSC address may be
+// sent at most a fixed number of times per second; messages that arrive
+// faster are coalesced so that only the latest value for an address is sent
+// once the address may be sent again. Optionally, the total bandwidth is
+// capped as well.
+type Throttle struct {
+	// BytesPerSecond caps the bandwidth of all messages sent by the
+	// Throttle. Bursts of up to one second worth of bytes are allowed. If
+	// zero, the bandwidth is not limited.
+	BytesPerSecond int
+
+	sender   Sender
+	interval time.Duration
+
+	mu      sync.Mutex
+	addrs   map[string]*throttledAddr
+	sweepAt int      // number of addresses at which idle ones are removed
+	order   []string // addresses with a pending message, oldest first
+	tokens  float64
+	last    time.Time
+	timer   *time.Timer
+	err     error
+}
+
+// throttledAddr is the state of one OSC address.
+type throttledAddr struct {
+	next    time.Time // earliest time the next message may be sent
+	pending *Message
+	size    int
+}
+
+// NewThrottle returns a new Throttle that sends through `sender` at most
+// `perAddress` messages per second for each OSC address. A zero `perAddress`
+// disables the per address limit.
+func NewThrottle(sender Sender, perAddress float64) *Throttle {
+	var interval time.Duration
+	if perAddress > 0 {
+		interval = time.Duration(float64(time.Second) / perAddress)
+	}
+
+	return &Throttle{
+		sender:   sender,
+		interval: interval,
+		addrs:    make(map[string]*throttledAddr),
+	}
+}
+
+// Send sends an OSC Message if its address and the bandwidth limit allow it
+// and otherwise keeps it until they do, replacing any message for the same
+// address that is still waiting. OSC Bundles are sent immediately but count
+// towards the bandwidth limit. Errors from delayed sends are reported by Err.
+func (t *Throttle) Send(packet Packet) error {
+	size, err := packetSize(packet)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.refill(now)
+
+	msg, ok := packet.(*Message)
+	if !ok {
+		t.tokens -= float64(size)
+		return t.sender.Send(packet)
+	}
+
+	a, ok := t.addrs[msg.Address]
+	if !ok {
+		t.sweep(now)
+
+		a = &throttledAddr{}
+		t.addrs[msg.Address] = a
+	}
+
+	// With a bandwidth limit, a message must not overtake waiting messages,
+	// which could otherwise be kept from being sent forever
+	bypass := t.BytesPerSecond <= 0 || len(t.order) == 0
+
+	if a.pending == nil && !now.Before(a.next) && bypass && t.allow(size) {
+		t.tokens -= float64(size)
+		a.next = now.Add(t.interval)
+		return t.sender.Send(msg)
+	}
+
+	if a.pending == nil {
+		t.order = append(t.order, msg.Address)
+	}
+	a.pending = msg
+	a.size = size
+
+	t.schedule(now)
+
+	return nil
+}
+
+// Flush sends all waiting messages immediately, ignoring the limits.
+func (t *Throttle) Flush() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+
+	now := time.Now()
+	t.refill(now)
+
+	for len(t.order) > 0 {
+		a := t.addrs[t.order[0]]
+		t.order = t.order[1:]
+
+		msg := a.pending
+		a.pending = nil
+		a.next = now.Add(t.interval)
+		t.tokens -= float64(a.size)
+
+		if err := t.sender.Send(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Err returns and clears the first error of a delayed send since the last
+// call to Err.
+func (t *Throttle) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := t.err
+	t.err = nil
+	return err
+}
+
+// Pending returns the number of messages waiting to be sent.
+func (t *Throttle) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.order)
+}
+
+// run sends the waiting messages that are due.
+func (t *Throttle) run() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.timer = nil
+	now := time.Now()
+	t.refill(now)
+
+	waiting := t.order[:0]
+	for i, addr := range t.order {
+		a := t.addrs[addr]
+
+		if now.Before(a.next) {
+			waiting = append(waiting, addr)
+			continue
+		}
+
+		// Keep the order of the remaining messages if the bandwidth is
+		// exhausted
+		if !t.allow(a.size) {
+			waiting = append(waiting, t.order[i:]...)
+			break
+		}
+
+		msg := a.pending
+		a.pending = nil
+		a.next = now.Add(t.interval)
+		t.tokens -= float64(a.size)
+
+		if err := t.sender.Send(msg); err != nil && t.err == nil {
+			t.err = err
+		}
+	}
+	t.order = waiting
+
+	t.schedule(now)
+}
+
+// schedule arms the timer for the next waiting message. The caller must
+// hold t.mu.
+func (t *Throttle) schedule(now time.Time) {
+	if t.timer != nil || len(t.order) == 0 {
+		return
+	}
+
+	var wake time.Time
+	for _, addr := range t.order {
+		a := t.addrs[addr]
+		at := a.next
+
+		if !t.allow(a.size) {
+			refillAt := now.Add(time.Duration((t.required(a.size) - t.tokens) /
+				float64(t.BytesPerSecond) * float64(time.Second)))
+			if refillAt.After(at) {
+				at = refillAt
+			}
+		}
+
+		if wake.IsZero() || at.Before(wake) {
+			wake = at
+		}
+	}
+
+	t.timer = time.AfterFunc(wake.Sub(now), t.run)
+}
+
+// sweep removes the addresses without a waiting message that may be sent
+// again, which are the same as unknown addresses, once the number of
+// addresses doubled since the last sweep. The caller must hold t.mu.
+func (t *Throttle) sweep(now time.Time) {
+	if len(t.addrs) < max(t.sweepAt, 64) {
+		return
+	}
+
+	for addr, a := range t.addrs {
+		if a.pending == nil && !now.Before(a.next) {
+			delete(t.addrs, addr)
+		}
+	}
+
+	t.sweepAt = 2 * len(t.addrs)
+}
+
+// refill adds the bandwidth tokens accumulated since the last refill. The
+// caller must hold t.mu.
+func (t *Throttle) refill(now time.Time) {
+	if t.BytesPerSecond <= 0 {
+		return
+	}
+
+	if t.last.IsZero() {
+		t.tokens = float64(t.BytesPerSecond)
+	} else {
+		t.tokens += now.Sub(t.last).Seconds() * float64(t.BytesPerSecond)
+		if t.tokens > float64(t.BytesPerSecond) {
+			t.tokens = float64(t.BytesPerSecond)
+		}
+	}
+	t.last = now
+}
+
+// allow reports whether `size` bytes may be sent now. The caller must hold
+// t.mu.
+func (t *Throttle) allow(size int) bool {
+	return t.BytesPerSecond <= 0 || t.tokens >= t.required(size)
+}
+
+// required returns the tokens needed to send `size` bytes. Messages larger
+// than the bucket may be sent once the bucket is full.
+func (t *Throttle) required(size int) float64 {
+	return float64(min(size, t.BytesPerSecond))
+}
@@ -0,0 +1,111 @@
+package osc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle(t *testing.T) {
+	t.Run("should keep the latest value per address", func(t *testing.T) {
+		r := &recordingSender{}
+		th := NewThrottle(r, 10)
+
+		for i := 0; i < 5; i++ {
+			assert.Nil(t, th.Send(mustMessage("/fader", int32(i))))
+		}
+		assert.Nil(t, th.Send(mustMessage("/other", int32(0))))
+
+		sent := r.sent()
+		assert.Equal(t, 2, len(sent))
+		assert.Equal(t, int32(0), sent[0].(*Message).Arguments[0])
+		assert.Equal(t, "/other", sent[1].(*Message).Address)
+		assert.Equal(t, 1, th.Pending())
+
+		assert.Eventually(t, func() bool { return len(r.sent()) == 3 }, time.Second, 5*time.Millisecond)
+		last := r.sent()[2].(*Message)
+		assert.Equal(t, "/fader", last.Address)
+		assert.Equal(t, int32(4), last.Arguments[0])
+		assert.Equal(t, 0, th.Pending())
+	})
+
+	t.Run("should limit bandwidth", func(t *testing.T) {
+		r := &recordingSender{}
+		th := NewThrottle(r, 0)
+		// Each message is 12 bytes marshalled.
+		th.BytesPerSecond = 240
+
+		start := time.Now()
+		for i := 0; i < 25; i++ {
+			assert.Nil(t, th.Send(mustMessage("/a"+string(rune('a'+i)), int32(i))))
+		}
+		assert.Equal(t, 20, len(r.sent()))
+
+		assert.Eventually(t, func() bool { return len(r.sent()) == 25 }, 2*time.Second, 5*time.Millisecond)
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	})
+
+	t.Run("should flush waiting messages", func(t *testing.T) {
+		r := &recordingSender{}
+		th := NewThrottle(r, 1)
+
+		assert.Nil(t, th.Send(mustMessage("/a", int32(1))))
+		assert.Nil(t, th.Send(mustMessage("/a", int32(2))))
+		assert.Equal(t, 1, len(r.sent()))
+
+		assert.Nil(t, th.Flush())
+		assert.Equal(t, 2, len(r.sent()))
+		assert.Equal(t, int32(2), r.sent()[1].(*Message).Arguments[0])
+	})
+
+	t.Run("should forget idle addresses", func(t *testing.T) {
+		r := &recordingSender{}
+		th := NewThrottle(r, 1e6)
+
+		for i := 0; i < 1000; i++ {
+			assert.Nil(t, th.Send(mustMessage(fmt.Sprintf("/a/%d", i))))
+			time.Sleep(time.Microsecond)
+		}
+		assert.Equal(t, 1000, len(r.sent()))
+
+		th.mu.Lock()
+		assert.LessOrEqual(t, len(th.addrs), 64)
+		th.mu.Unlock()
+	})
+
+	t.Run("should not let messages overtake waiting ones", func(t *testing.T) {
+		r := &recordingSender{}
+		th := NewThrottle(r, 0)
+		th.BytesPerSecond = 100
+
+		assert.Nil(t, th.Send(mustMessage("/a", int32(0))))
+		assert.Nil(t, th.Send(mustMessage("/big", make([]byte, 200))))
+		assert.Nil(t, th.Send(mustMessage("/c", int32(0))))
+		assert.Equal(t, 1, len(r.sent()))
+		assert.Equal(t, 2, th.Pending())
+
+		assert.Nil(t, th.Flush())
+		sent := r.sent()
+		assert.Equal(t, 3, len(sent))
+		assert.Equal(t, "/big", sent[1].(*Message).Address)
+		assert.Equal(t, "/c", sent[2].(*Message).Address)
+	})
+
+	t.Run("should report errors of delayed sends", func(t *testing.T) {
+		f := &failingSender{}
+		th := NewThrottle(f, 100)
+
+		assert.Nil(t, th.Send(mustMessage("/a", int32(1))))
+		f.setFail(true)
+		assert.Nil(t, th.Send(mustMessage("/a", int32(2))))
+		assert.Eventually(t, func() bool { return th.Pending() == 0 }, time.Second, 5*time.Millisecond)
+
+		f.setFail(false)
+		assert.Nil(t, th.Send(mustMessage("/b", int32(3))))
+		assert.Equal(t, 2, len(f.sent()))
+		assert.EqualError(t, th.Err(), "send failed")
+		assert.Nil(t, th.Err())
+	})
+}
@@ -1,7 +1,6 @@
 package osc
 
 import (
-	"bytes"
 	"encoding/binary"
 	"time"
 )
@@ -53,9 +52,19 @@ func (t Timetag) SecondsSinceEpoch() uint32 {
 
 // MarshalBinary converts the OSC time tag to a byte array.
 func (t Timetag) MarshalBinary() ([]byte, error) {
-	data := new(bytes.Buffer)
-	err := binary.Write(data, binary.BigEndian, t)
-	return data.Bytes(), err
+	return t.AppendBinary(make([]byte, 0, t.Size()))
+}
+
+// AppendBinary appends the binary representation of the OSC time tag to
+// `dst` and returns the extended buffer.
+func (t Timetag) AppendBinary(dst []byte) ([]byte, error) {
+	return binary.BigEndian.AppendUint64(dst, uint64(t)), nil
+}
+
+// Size returns the number of bytes of the binary representation of the OSC
+// time tag.
+func (t Timetag) Size() int {
+	return 8
 }
 
 // ExpiresIn calculates the number of seconds until the current time is the same as the value of the time tag.
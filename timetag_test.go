@@ -39,3 +39,12 @@ func TestTimetag(t *testing.T) {
 		assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 1}, actual)
 	})
 }
+
+func TestTimetagAppendBinary(t *testing.T) {
+	tt := Timetag(0x0102030405060708)
+
+	data, err := tt.AppendBinary([]byte{9})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{9, 1, 2, 3, 4, 5, 6, 7, 8}, data)
+	assert.Equal(t, 8, tt.Size())
+}
@@ -0,0 +1,83 @@
+package osc
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// isAbstractSocket returns true if `addr` is a name in the abstract namespace
+// of unix domain sockets, which has no socket file.
+func isAbstractSocket(addr string) bool {
+	return strings.HasPrefix(addr, "@")
+}
+
+// listenUnix creates the unix domain socket `addr` with `listen`. If `mode`
+// isn't zero, the socket is created in a private directory next to `addr`,
+// given the permissions `mode` and then linked to `addr`, so that it is never
+// accessible with the permissions of the umask. The socket path must leave
+// room for the private directory, about 16 bytes, within the limit of unix
+// socket paths. Abstract names have no permissions and are created directly.
+func listenUnix[T io.Closer](addr string, mode os.FileMode, listen func(addr string) (T, error)) (T, error) {
+	if mode == 0 || isAbstractSocket(addr) {
+		return listen(addr)
+	}
+
+	var zero T
+
+	dir, err := os.MkdirTemp(filepath.Dir(addr), ".osc")
+	if err != nil {
+		return zero, err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "s")
+
+	ln, err := listen(path)
+	if err != nil {
+		return zero, err
+	}
+
+	// Unlike a rename, linking fails if `addr` exists
+	err = os.Chmod(path, mode)
+	if err == nil {
+		err = os.Link(path, addr)
+	}
+	if err != nil {
+		ln.Close()
+		return zero, err
+	}
+
+	return ln, nil
+}
+
+// removeUnixSocket removes the socket file `addr`. Unlike listeners of unix
+// stream sockets, datagram sockets don't remove their file when closed.
+func removeUnixSocket(addr string) {
+	if !isAbstractSocket(addr) {
+		_ = os.Remove(addr)
+	}
+}
+
+// unixListener is a unix stream socket listener created by listenUnix with
+// permissions. It reports and, once closed, removes the socket file `path`
+// instead of the file it was created at.
+type unixListener struct {
+	net.Listener
+	path string
+	once sync.Once
+}
+
+func (l *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: "unix"}
+}
+
+func (l *unixListener) Close() error {
+	err := l.Listener.Close()
+	l.once.Do(func() { removeUnixSocket(l.path) })
+
+	return err
+}
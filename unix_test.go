@@ -0,0 +1,109 @@
+package osc
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// messageChannel returns a dispatcher sending the received messages to the
+// returned channel.
+func messageChannel(t *testing.T) (*StandardDispatcher, chan *Message) {
+	received := make(chan *Message, 16)
+
+	d := NewStandardDispatcher()
+	assert.Nil(t, d.AddMsgHandler("*", func(msg *Message) {
+		received <- msg
+	}))
+
+	return d, received
+}
+
+// sendUntil sends the message until it succeeds, e.g. once the server
+// listens.
+func sendUntil(t *testing.T, s Sender, msg *Message) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := s.Send(msg)
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func expectMessage(t *testing.T, received chan *Message, want *Message) {
+	select {
+	case msg := <-received:
+		assert.Equal(t, want, msg)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%v wasn't received", want)
+	}
+}
+
+func TestUnixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "osc.sock")
+	d, received := messageChannel(t)
+
+	server := &Server{Network: "unixgram", Addr: path, Dispatcher: d, SocketMode: 0o600}
+	done := make(chan error)
+	go func() {
+		done <- server.ListenAndServe()
+	}()
+
+	client := NewUnixClient(path)
+	sendUntil(t, client, mustMessage("/unix", int32(1)))
+	expectMessage(t, received, mustMessage("/unix", int32(1)))
+
+	fi, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	// The private directory the socket was created in is removed
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+
+	// The socket file is removed on close
+	assert.Nil(t, server.Close())
+	assert.NotNil(t, <-done)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUnixSocketExists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "osc.sock")
+	assert.Nil(t, os.WriteFile(path, []byte("keep"), 0o644))
+
+	// Sockets with permissions don't replace existing files either
+	for _, mode := range []os.FileMode{0, 0o600} {
+		server := &Server{Network: "unixgram", Addr: path, SocketMode: mode}
+		assert.NotNil(t, server.ListenAndServe())
+
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.Equal(t, "keep", string(data))
+	}
+}
+
+func TestUnixgramAbstract(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("abstract unix sockets are only supported on Linux")
+	}
+
+	d, received := messageChannel(t)
+
+	server := &Server{Network: "unixgram", Addr: "@go-osc-test", Dispatcher: d, SocketMode: 0o600}
+	go server.ListenAndServe()
+	defer server.Close()
+
+	sendUntil(t, NewUnixClient("@go-osc-test"), mustMessage("/abstract"))
+	expectMessage(t, received, mustMessage("/abstract"))
+}
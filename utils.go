@@ -62,6 +62,9 @@ func getTypeTag(arg any) byte {
 	case Timetag:
 		return 't'
 	default:
+		if ct := customTypeOf(arg); ct != nil {
+			return ct.Tag
+		}
 		return '\xff'
 	}
 }
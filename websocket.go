@@ -0,0 +1,267 @@
+package osc
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// ErrorWebSocketPeer is returned when sending to an address that isn't a
+// connected peer of the WebSocketServer.
+var ErrorWebSocketPeer = errors.New("not a connected WebSocket peer")
+
+// WebSocketAddr is the address of a WebSocket peer.
+type WebSocketAddr struct {
+	// RemoteAddr is the network address of the peer, e.g. "127.0.0.1:1234".
+	RemoteAddr string
+
+	ws *websocket.Conn
+}
+
+// Network returns "websocket". Implements the net.Addr interface.
+func (a *WebSocketAddr) Network() string {
+	return "websocket"
+}
+
+// String returns the network address of the peer. Implements the net.Addr
+// interface.
+func (a *WebSocketAddr) String() string {
+	return a.RemoteAddr
+}
+
+// WebSocketServer is an http.Handler receiving OSC packets from WebSocket
+// clients, e.g. web browsers. Each binary message frame carries one OSC
+// packet; text frames are ignored. Received packets are dispatched with the
+// *WebSocketAddr of the peer as address, which SendTo accepts to answer.
+type WebSocketServer struct {
+	// Dispatcher dispatches the received packets. If nil, a
+	// StandardDispatcher is created on first use.
+	Dispatcher Dispatcher
+
+	// CheckOrigin returns true if a WebSocket handshake request is accepted.
+	// If nil, all origins are accepted.
+	CheckOrigin func(r *http.Request) bool
+
+	mu    sync.Mutex
+	peers map[*WebSocketAddr]struct{}
+}
+
+// NewWebSocketServer returns a WebSocketServer dispatching the received
+// packets to `dispatcher`.
+func NewWebSocketServer(dispatcher Dispatcher) *WebSocketServer {
+	return &WebSocketServer{Dispatcher: dispatcher}
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection and dispatches the
+// received packets until the connection is closed or a packet can't be
+// decoded or dispatched. Implements the http.Handler interface.
+func (s *WebSocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if s.Dispatcher == nil {
+		s.Dispatcher = NewStandardDispatcher()
+	}
+	s.mu.Unlock()
+
+	websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			if s.CheckOrigin != nil && !s.CheckOrigin(r) {
+				return websocket.ErrBadWebSocketOrigin
+			}
+			return nil
+		},
+		Handler: s.serve,
+	}.ServeHTTP(w, r)
+}
+
+func (s *WebSocketServer) serve(ws *websocket.Conn) {
+	addr := &WebSocketAddr{RemoteAddr: ws.Request().RemoteAddr, ws: ws}
+
+	s.mu.Lock()
+	if s.peers == nil {
+		s.peers = make(map[*WebSocketAddr]struct{})
+	}
+	s.peers[addr] = struct{}{}
+	dispatcher := s.Dispatcher
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.peers, addr)
+		s.mu.Unlock()
+
+		ws.Close()
+	}()
+
+	for {
+		packet, err := receiveWebSocket(ws)
+		if err != nil {
+			return
+		}
+
+		if err := dispatcher.Dispatch(packet, addr); err != nil {
+			return
+		}
+	}
+}
+
+// Peers returns the addresses of the connected peers.
+func (s *WebSocketServer) Peers() []net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	addrs := make([]net.Addr, 0, len(s.peers))
+	for addr := range s.peers {
+		addrs = append(addrs, addr)
+	}
+
+	return addrs
+}
+
+// SendTo sends the packet to the peer `addr`, which must be a *WebSocketAddr
+// of the server, e.g. the address passed to the dispatcher.
+func (s *WebSocketServer) SendTo(addr net.Addr, packet Packet) error {
+	peer, ok := addr.(*WebSocketAddr)
+
+	s.mu.Lock()
+	_, connected := s.peers[peer]
+	s.mu.Unlock()
+
+	if !ok || !connected {
+		return ErrorWebSocketPeer
+	}
+
+	return sendWebSocket(peer.ws, packet)
+}
+
+// Broadcast sends the packet to all connected peers. Peers that fail to
+// receive it are disconnected; the first error is returned.
+func (s *WebSocketServer) Broadcast(packet Packet) error {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+
+	for _, addr := range s.Peers() {
+		ws := addr.(*WebSocketAddr).ws
+
+		if err := websocket.Message.Send(ws, data); err != nil {
+			ws.Close()
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// Close disconnects all peers.
+func (s *WebSocketServer) Close() error {
+	for _, addr := range s.Peers() {
+		addr.(*WebSocketAddr).ws.Close()
+	}
+
+	return nil
+}
+
+// WebSocketClient sends and receives OSC packets over a WebSocket connection,
+// one packet per binary message frame.
+type WebSocketClient struct {
+	ws *websocket.Conn
+}
+
+// DialWebSocket connects to the WebSocket server at `url`, e.g.
+// "ws://localhost:8080/osc". The `origin` is sent in the Origin header; if
+// empty, `url` is used.
+func DialWebSocket(url, origin string) (*WebSocketClient, error) {
+	if origin == "" {
+		origin = url
+	}
+
+	ws, err := websocket.Dial(url, "", origin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebSocketClient{ws: ws}, nil
+}
+
+// Send sends the packet to the server. Implements the Sender interface.
+func (c *WebSocketClient) Send(packet Packet) error {
+	return sendWebSocket(c.ws, packet)
+}
+
+// Receive returns the next OSC packet sent by the server. Text frames are
+// skipped.
+func (c *WebSocketClient) Receive() (Packet, error) {
+	return receiveWebSocket(c.ws)
+}
+
+// Serve dispatches the received packets to `dispatcher` until the connection
+// is closed or the dispatcher returns an error.
+func (c *WebSocketClient) Serve(dispatcher Dispatcher) error {
+	for {
+		packet, err := c.Receive()
+		if err != nil {
+			return err
+		}
+
+		if err := dispatcher.Dispatch(packet, c.RemoteAddr()); err != nil {
+			return err
+		}
+	}
+}
+
+// RemoteAddr returns the address of the server.
+func (c *WebSocketClient) RemoteAddr() net.Addr {
+	return c.ws.RemoteAddr()
+}
+
+// Close closes the connection.
+func (c *WebSocketClient) Close() error {
+	return c.ws.Close()
+}
+
+// wsFrame is a received WebSocket frame.
+type wsFrame struct {
+	payloadType byte
+	data        []byte
+}
+
+// frameCodec receives WebSocket frames along with their payload type.
+var frameCodec = websocket.Codec{
+	Unmarshal: func(data []byte, payloadType byte, v any) error {
+		*v.(*wsFrame) = wsFrame{payloadType, data}
+		return nil
+	},
+}
+
+// receiveWebSocket returns the packet of the next binary frame received by
+// `ws`. Text frames are skipped.
+func receiveWebSocket(ws *websocket.Conn) (Packet, error) {
+	for {
+		var f wsFrame
+		if err := frameCodec.Receive(ws, &f); err != nil {
+			return nil, err
+		}
+
+		if f.payloadType == websocket.BinaryFrame {
+			return ParsePacket(f.data)
+		}
+	}
+}
+
+// sendWebSocket sends the packet as binary frame.
+func sendWebSocket(ws *websocket.Conn, packet Packet) error {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return websocket.Message.Send(ws, data)
+}
@@ -0,0 +1,129 @@
+package osc
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+func newTestWebSocketServer(t *testing.T, d Dispatcher) (*WebSocketServer, string) {
+	s := NewWebSocketServer(d)
+	ts := httptest.NewServer(s)
+	t.Cleanup(func() {
+		s.Close()
+		ts.Close()
+	})
+
+	return s, "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+// waitPeers waits until `s` has `n` connected peers.
+func waitPeers(t *testing.T, s *WebSocketServer, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.Peers()) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d peers, want %d", len(s.Peers()), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWebSocketServer(t *testing.T) {
+	var s *WebSocketServer
+
+	d := NewStandardDispatcher()
+	assert.Nil(t, d.AddMsgHandlerExt("/ping", func(msg *Message, addr net.Addr) {
+		assert.Equal(t, "websocket", addr.Network())
+		assert.Nil(t, s.SendTo(addr, mustMessage("/pong", msg.Arguments...)))
+	}))
+
+	s, url := newTestWebSocketServer(t, d)
+
+	c, err := DialWebSocket(url, "")
+	assert.Nil(t, err)
+	defer c.Close()
+
+	assert.Nil(t, c.Send(mustMessage("/ping", int32(1), "x")))
+
+	p, err := c.Receive()
+	assert.Nil(t, err)
+	assert.Equal(t, mustMessage("/pong", int32(1), "x"), p)
+
+	assert.ErrorIs(t, s.SendTo(&net.UDPAddr{}, p), ErrorWebSocketPeer)
+}
+
+func TestWebSocketBroadcast(t *testing.T) {
+	s, url := newTestWebSocketServer(t, nil)
+
+	var clients []*WebSocketClient
+	for i := 0; i < 3; i++ {
+		c, err := DialWebSocket(url, "")
+		assert.Nil(t, err)
+		defer c.Close()
+		clients = append(clients, c)
+	}
+	waitPeers(t, s, 3)
+
+	bundle := NewBundle(time.Time{})
+	assert.Nil(t, bundle.Append(mustMessage("/level", float32(0.5))))
+	assert.Nil(t, s.Broadcast(bundle))
+
+	for _, c := range clients {
+		p, err := c.Receive()
+		assert.Nil(t, err)
+		assert.Equal(t, bundle, p)
+	}
+
+	// Closed clients are removed
+	clients[0].Close()
+	waitPeers(t, s, 2)
+}
+
+func TestWebSocketClientServe(t *testing.T) {
+	s, url := newTestWebSocketServer(t, nil)
+
+	// Text frames are skipped
+	ws, err := websocket.Dial(url, "", "http://localhost")
+	assert.Nil(t, err)
+	c := &WebSocketClient{ws: ws}
+	defer c.Close()
+	waitPeers(t, s, 1)
+
+	received := make(chan *Message, 1)
+	d := NewStandardDispatcher()
+	assert.Nil(t, d.AddMsgHandler("/status", func(msg *Message) {
+		received <- msg
+	}))
+	go c.Serve(d)
+
+	peer := s.Peers()[0].(*WebSocketAddr)
+	assert.Nil(t, websocket.Message.Send(peer.ws, "hello"))
+	assert.Nil(t, s.SendTo(peer, mustMessage("/status", "ok")))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, ArgumentsType{"ok"}, msg.Arguments)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message wasn't dispatched")
+	}
+}
+
+func TestWebSocketCheckOrigin(t *testing.T) {
+	s, url := newTestWebSocketServer(t, nil)
+	s.CheckOrigin = func(r *http.Request) bool {
+		return r.Header.Get("Origin") == "http://panel.local"
+	}
+
+	_, err := DialWebSocket(url, "http://evil.local")
+	assert.NotNil(t, err)
+
+	c, err := DialWebSocket(url, "http://panel.local")
+	assert.Nil(t, err)
+	c.Close()
+}